@@ -0,0 +1,335 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Config controls how the test suites are run.
+// The zero value runs every suite with its default sizes.
+type Config struct {
+	// Sizes, if set, overrides the number of items used by every suite.
+	Sizes []int
+
+	// SuiteSizes, if set, overrides the number of items used by individual suites.
+	// The map is keyed by the suite name, i.e. "Fill", "Refill", "Microservice".
+	// SuiteSizes takes precedence over Sizes.
+	SuiteSizes map[string][]int
+
+	// FillCount, if set, overrides the number of items the Stable, RefillFull and SlowDecrease
+	// suites fill the data structures with before running the tests. FillCount should be large
+	// enough to fill at least three internal slices of the data structure being tested.
+	// Defaults to 10000.
+	FillCount int
+
+	// RefillCount, if set, overrides the number of times the Refill and RefillFull suites
+	// repeat the test using the same data structure instance. Defaults to 100.
+	RefillCount int
+
+	// Validate, if set, validates the data structures return the items in the given order.
+	// Every removed value is checked against a reference model and the benchmark fails on the
+	// first value returned out of order. Validation adds overhead to the tests, so the timings
+	// of validated runs should not be published. The deque tests validate the items against a
+	// deque model, regardless of the order.
+	Validate Order
+
+	// PhaseMetrics, if set, reports the time, allocations and allocated bytes of each phase of
+	// the Microservice test and of the scenarios as custom metrics, i.e. spike-ns/op,
+	// spike-allocs/op and spike-B/op.
+	// The aggregate results are still reported. Measuring the phases adds overhead to the tests,
+	// in the order of 100ns per phase change, so the ns/op results of runs with PhaseMetrics set
+	// should not be compared to runs without it.
+	PhaseMetrics bool
+
+	// Latencies, if set, measures the latency of every add and remove and reports the 50th, 90th,
+	// 99th and 99.9th percentiles and the maximum latency of the adds and removes as custom
+	// metrics, i.e. add-p99-ns and remove-max-ns. The time it takes to read the clock is measured
+	// once and subtracted from the latencies, and is reported as timer-overhead-ns.
+	// Measuring the latencies adds overhead to the tests, so the ns/op results of runs with
+	// Latencies set should not be compared to runs without it.
+	Latencies bool
+
+	// WorstOps, if set, logs the given number of slowest add and remove operations of each benchmark run,
+	// with the number of items in the data structure when they ran, i.e. "100µs add at len=65536". The
+	// slowest operation is also reported as custom metrics (worst-ns, worst-len and worst-cap). Tracking the
+	// slowest operations times every operation, adding the same overhead to the tests as Latencies.
+	WorstOps int
+
+	// Cap, if set, returns the capacity of the data structure being tested. The capacity after each of the
+	// slowest operations is reported with them, so the slow operations can be correlated with the data
+	// structure growing or shrinking.
+	Cap func() int
+
+	// Memory, if set, reports the heap held by the data structure when holding the most items in each
+	// test, per item (peak-B/item) and in total (peak-B), and, in the tests that drain it, the heap it
+	// still holds after being drained (retained-B) as custom metrics. The heap is measured after a garbage collection, relative to a newly
+	// initialized data structure instance, and doesn't include the heap held by the values added to the
+	// data structure, i.e. the *TestValue objects. The time spent measuring the heap is not included in
+	// the results. The Validate reference models also hold the items, so Memory should not be combined
+	// with Validate. With ValuePool, the pooled values are measured with the newly initialized instance,
+	// telling them apart with TypedTests.Count, so the results are the same with and without the pool.
+	Memory bool
+
+	// ShrinkLimits, if set, fails the tests that drain the data structure if the data structure retains more
+	// heap after being drained than the limits allow. Setting ShrinkLimits enables the Memory metrics.
+	ShrinkLimits *ShrinkLimits
+
+	// ValuePool, if set, builds the values added to the data structures before running each test, outside
+	// of the timed region, and reuses them in every benchmark iteration, so the results, including the
+	// allocs/op and B/op reported by -benchmem, include only the work done by the data structures.
+	// The values are reused, so the same value may be in the data structure more than once, i.e. in the
+	// SlowIncrease test. The values of the items added beyond the number of items of the test, i.e. by
+	// the PriorityStable test, are still built when added.
+	ValuePool bool
+
+	// Overhead, if set, first runs every test against a built-in null data structure, which does no work,
+	// as a sub-benchmark named null/n, i.e. BenchmarkFill/null/100 before BenchmarkFill/100, measuring the
+	// overhead of the harness, the test and the calls through the initInstance, add, remove and empty
+	// functions. The overhead is a large share of the results of the smallest tests, so subtracting it from
+	// the results gives a better comparison of the data structures themselves. The concurrent tests call the
+	// data structures directly and don't measure the overhead.
+	Overhead bool
+
+	// Mix configures the mix of operations of the Random test.
+	Mix Mix
+
+	// PeekRatio, if set, overrides the number of times the PeekHeavy test peeks the next item
+	// for each removed item. Defaults to 10.
+	PeekRatio int
+
+	// Len, if set, returns the number of items in the data structure being tested. The PeekHeavy
+	// test uses Len, instead of empty, to check whether the data structure has items, and the
+	// SlowIncrease and SlowDecrease tests check the number of items with Len, outside of the timed
+	// region, at the end of each benchmark run.
+	Len func() int
+
+	// Priorities, if set, overrides the distributions of the priorities the priority queue tests
+	// run with. Defaults to all distributions.
+	Priorities []Priorities
+
+	// Producers and Consumers, if set, override the number of producer and consumer goroutines the
+	// concurrent tests run with. The tests run with every combination of producers and consumers.
+	// Both default to 1, 2 and 4.
+	Producers []int
+	Consumers []int
+}
+
+var (
+	// sizes contains the number of items to add to the data structures in each test.
+	sizes = []int{
+		0,
+		1,
+		10,
+		100,
+		1000,    // 1k
+		10000,   //10k
+		100000,  // 100k
+		1000000, // 1mi
+	}
+
+	// suiteSizes contains the number of items each suite runs with by default.
+	suiteSizes = map[string][]int{
+		"Fill": sizes,
+
+		// Refill and RefillFull don't run the first (0 items) and last (1mi) items tests
+		// as 0 items makes no sense for these tests and 1mi is too slow.
+		"Refill":     sizes[1:7],
+		"RefillFull": sizes[1:7],
+
+		// SlowIncrease, SlowDecrease and Stable don't run the first (0 items) test
+		// as 0 items makes no sense for these tests.
+		"SlowIncrease": sizes[1:],
+		"SlowDecrease": sizes[1:],
+		"Stable":       sizes[1:],
+
+		"Microservice": sizes,
+
+		// Random doesn't run the first (0 items) test as 0 items makes no sense for this test.
+		"Random": sizes[1:],
+
+		// DequeReverse doesn't run the first (0 items) test as 0 items makes no sense for this test.
+		"DequeAlternate":    sizes,
+		"DequeReverse":      sizes[1:],
+		"DequeMicroservice": sizes,
+
+		// PriorityRefill doesn't run the first (0 items) and last (1mi) items tests
+		// as 0 items makes no sense for this test and 1mi is too slow.
+		// PriorityStable doesn't run the first (0 items) test as 0 items makes no sense for this test.
+		"PriorityFill":         sizes,
+		"PriorityRefill":       sizes[1:7],
+		"PriorityStable":       sizes[1:],
+		"PriorityMicroservice": sizes,
+		"PriorityUpdate":       sizes[1:],
+
+		// PeekHeavy doesn't run the first (0 items) test as 0 items makes no sense for this test.
+		"PeekHeavy": sizes[1:],
+
+		// ProducerConsumer and ProducerConsumerFull don't run the first (0 items) and last (1mi) items tests
+		// as 0 items makes no sense for these tests and 1mi is too slow, as each size runs with every
+		// combination of producers and consumers.
+		"ProducerConsumer":     sizes[1:7],
+		"ProducerConsumerFull": sizes[1:7],
+
+		// Linearizability doesn't run the first (0 items) test as 0 items makes no sense for this test,
+		// and runs up to 100 items as checking longer histories is too slow.
+		"Linearizability": sizes[1:4],
+
+		// Shrink doesn't run the first (0 items) test as 0 items makes no sense for this test.
+		"Shrink": sizes[1:],
+
+		// Leak doesn't run the first (0 items) and last (1mi) items tests as 0 items makes no sense for this
+		// test and 1mi is too slow, as every item has a finalizer.
+		"Leak": sizes[1:7],
+	}
+
+	// fillCount is the default number of items used to fill the data structures before running
+	// the Stable, RefillFull and SlowDecrease tests.
+	fillCount = 10000
+
+	// refillCount is the default number of times the Refill and RefillFull tests are repeated.
+	refillCount = 100
+
+	// peekRatio is the default number of peeks per removed item of the PeekHeavy test.
+	peekRatio = 10
+
+	// goroutines is the default number of producer and consumer goroutines of the concurrent tests.
+	goroutines = []int{1, 2, 4}
+
+	// sizesFlag holds the value of the -benchmark.sizes flag.
+	sizesFlag sizeList
+
+	// fillCountFlag and refillCountFlag hold the values of the -benchmark.fillcount and
+	// -benchmark.refillcount flags.
+	fillCountFlag   = flag.Int("benchmark.fillcount", 0, "number of items used to fill the data structures before running the Stable, RefillFull and SlowDecrease tests (default 10000)")
+	refillCountFlag = flag.Int("benchmark.refillcount", 0, "number of times the Refill and RefillFull tests are repeated (default 100)")
+
+	// seedFlag holds the value of the -benchmark.seed flag.
+	seedFlag = flag.Int64("benchmark.seed", 0, "seed used to generate the operations of the Random test (default random)")
+)
+
+func init() {
+	flag.Var(&sizesFlag, "benchmark.sizes", "comma separated `list` of the number of items used by every suite, i.e. 100,10000")
+}
+
+// sizes returns the number of items the suite runs with.
+// Sizes set in the config take precedence over the -benchmark.sizes flag, which
+// takes precedence over the suite's default sizes.
+func (c *Config) sizes(suite string) []int {
+	return c.sizesOr(suite, nil)
+}
+
+// sizesOr returns the number of items the suite runs with, using defaults as the suite's
+// default sizes. If defaults is nil, the suite's declared default sizes are used, or all
+// sizes if the suite declares none.
+func (c *Config) sizesOr(suite string, defaults []int) []int {
+	if s, ok := c.SuiteSizes[suite]; ok {
+		return s
+	}
+	if c.Sizes != nil {
+		return c.Sizes
+	}
+	if sizesFlag != nil {
+		return sizesFlag
+	}
+	if defaults != nil {
+		return defaults
+	}
+	if s, ok := suiteSizes[suite]; ok {
+		return s
+	}
+	return sizes
+}
+
+// fillCount returns the number of items used to fill the data structures before running the tests.
+func (c *Config) fillCount() int {
+	if c.FillCount > 0 {
+		return c.FillCount
+	}
+	if *fillCountFlag > 0 {
+		return *fillCountFlag
+	}
+	return fillCount
+}
+
+// refillCount returns the number of times the refill tests are repeated.
+func (c *Config) refillCount() int {
+	if c.RefillCount > 0 {
+		return c.RefillCount
+	}
+	if *refillCountFlag > 0 {
+		return *refillCountFlag
+	}
+	return refillCount
+}
+
+// peekRatio returns the number of peeks per removed item of the PeekHeavy test.
+func (c *Config) peekRatio() int {
+	if c.PeekRatio > 0 {
+		return c.PeekRatio
+	}
+	return peekRatio
+}
+
+// producers returns the number of producer goroutines the concurrent tests run with.
+func (c *Config) producers() []int {
+	if c.Producers != nil {
+		return c.Producers
+	}
+	return goroutines
+}
+
+// consumers returns the number of consumer goroutines the concurrent tests run with.
+func (c *Config) consumers() []int {
+	if c.Consumers != nil {
+		return c.Consumers
+	}
+	return goroutines
+}
+
+// sizeList is a flag.Value holding a comma separated list of sizes.
+type sizeList []int
+
+func (l *sizeList) String() string {
+	s := make([]string, len(*l))
+	for i, v := range *l {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *sizeList) Set(value string) error {
+	var list sizeList
+	for _, s := range strings.Split(value, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid size %q", s)
+		}
+		list = append(list, v)
+	}
+	*l = list
+	return nil
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import "testing"
+
+// DequeAlternate tests the deques performance by sequentially adding n items alternating between the front and
+// the back of the deque, and then removing all added items alternating between the front and the back.
+// DequeAlternate tests the deques ability to quickly expand and shrink on both ends at the same time.
+func (t *Tests) DequeAlternate(b *testing.B, initInstance func(), pushFront func(v interface{}), pushBack func(v interface{}), popFront func() (interface{}, bool), popBack func() (interface{}, bool), empty func() bool) {
+	t.untyped().DequeAlternate(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+}
+
+// DequeAlternateTestObject tests the deques performance by sequentially adding n items alternating between the front and
+// the back of the deque, and then removing all added items alternating between the front and the back.
+// DequeAlternateTestObject is a version of DequeAlternate that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) DequeAlternateTestObject(b *testing.B, initInstance func(), pushFront func(v *TestValue), pushBack func(v *TestValue), popFront func() (*TestValue, bool), popBack func() (*TestValue, bool), empty func() bool) {
+	t.testObject().DequeAlternate(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+}
+
+// DequeAlternate tests the deques performance by sequentially adding n items alternating between the front and
+// the back of the deque, and then removing all added items alternating between the front and the back.
+// DequeAlternate tests the deques ability to quickly expand and shrink on both ends at the same time.
+func (t *TypedTests[T]) DequeAlternate(b *testing.B, initInstance func(), pushFront func(v T), pushBack func(v T), popFront func() (T, bool), popBack func() (T, bool), empty func() bool) {
+	h := t.dequeHarness(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+	for _, count := range t.sizes("DequeAlternate") {
+		h.run(count, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				h.init()
+				for i := 0; i < count; i++ {
+					if i%2 == 0 {
+						h.pushFront(i)
+					} else {
+						h.add(i)
+					}
+				}
+				h.footprint()
+				for i := 0; !h.empty(); i++ {
+					if i%2 == 0 {
+						h.remove()
+					} else {
+						h.popBack()
+					}
+				}
+				h.footprint()
+			}
+		})
+	}
+}
+
+// DequeReverse tests the deques performance by filling the deque with n items, then sequentially adding 1 item to the
+// back and removing 1 item from the front n times, and then reversing the direction, adding 1 item to the front and
+// removing 1 item from the back n times.
+// DequeReverse tests the deques ability to handle the items moving towards one end and then towards the other end.
+func (t *Tests) DequeReverse(b *testing.B, initInstance func(), pushFront func(v interface{}), pushBack func(v interface{}), popFront func() (interface{}, bool), popBack func() (interface{}, bool), empty func() bool) {
+	t.untyped().DequeReverse(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+}
+
+// DequeReverseTestObject tests the deques performance by filling the deque with n items, then sequentially adding 1 item to the
+// back and removing 1 item from the front n times, and then reversing the direction, adding 1 item to the front and
+// removing 1 item from the back n times.
+// DequeReverseTestObject is a version of DequeReverse that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) DequeReverseTestObject(b *testing.B, initInstance func(), pushFront func(v *TestValue), pushBack func(v *TestValue), popFront func() (*TestValue, bool), popBack func() (*TestValue, bool), empty func() bool) {
+	t.testObject().DequeReverse(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+}
+
+// DequeReverse tests the deques performance by filling the deque with n items, then sequentially adding 1 item to the
+// back and removing 1 item from the front n times, and then reversing the direction, adding 1 item to the front and
+// removing 1 item from the back n times.
+// DequeReverse tests the deques ability to handle the items moving towards one end and then towards the other end.
+func (t *TypedTests[T]) DequeReverse(b *testing.B, initInstance func(), pushFront func(v T), pushBack func(v T), popFront func() (T, bool), popBack func() (T, bool), empty func() bool) {
+	h := t.dequeHarness(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+	for _, count := range t.sizes("DequeReverse") {
+		h.run(count, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				h.init()
+				for i := 0; i < count; i++ {
+					h.add(i)
+				}
+				h.footprint()
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.remove()
+				}
+				for i := 0; i < count; i++ {
+					h.pushFront(i)
+					h.popBack()
+				}
+				for !h.empty() {
+					h.remove()
+				}
+				h.footprint()
+			}
+		})
+	}
+}
+
+// DequeMicroservice tests the deques performance by simulating the deque being used by microservice
+// and serverless systems when running in production environments, adding and removing items on both ends of the deque.
+func (t *Tests) DequeMicroservice(b *testing.B, initInstance func(), pushFront func(v interface{}), pushBack func(v interface{}), popFront func() (interface{}, bool), popBack func() (interface{}, bool), empty func() bool) {
+	t.untyped().DequeMicroservice(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+}
+
+// DequeMicroserviceTestObject tests the deques performance by simulating the deque being used by microservice
+// and serverless systems when running in production environments, adding and removing items on both ends of the deque.
+// DequeMicroserviceTestObject is a version of DequeMicroservice that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) DequeMicroserviceTestObject(b *testing.B, initInstance func(), pushFront func(v *TestValue), pushBack func(v *TestValue), popFront func() (*TestValue, bool), popBack func() (*TestValue, bool), empty func() bool) {
+	t.testObject().DequeMicroservice(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+}
+
+// DequeMicroservice tests the deques performance by simulating the deque being used by microservice
+// and serverless systems when running in production environments, adding and removing items on both ends of the deque.
+func (t *TypedTests[T]) DequeMicroservice(b *testing.B, initInstance func(), pushFront func(v T), pushBack func(v T), popFront func() (T, bool), popBack func() (T, bool), empty func() bool) {
+	h := t.dequeHarness(b, initInstance, pushFront, pushBack, popFront, popBack, empty)
+	for _, count := range t.sizes("DequeMicroservice") {
+		h.run(count, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				h.init()
+
+				// Simulate stable traffic
+				h.phase("stable")
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.remove()
+				}
+
+				// Simulate slowly increasing traffic, adding to both ends
+				h.phase("slow-increase")
+				for i := 0; i < count; i++ {
+					h.pushFront(i)
+					h.add(i)
+					h.remove()
+				}
+
+				// Simulate slowly decreasing traffic, bringing traffic back to normal
+				h.phase("slow-decrease")
+				for i := 0; i < count; i++ {
+					h.popBack()
+					if !h.empty() {
+						h.remove()
+					}
+					h.pushFront(i)
+				}
+
+				// Simulate quick traffic spike (DDOS attack, etc), adding to both ends
+				h.phase("spike")
+				for i := 0; i < count; i++ {
+					if i%2 == 0 {
+						h.pushFront(i)
+					} else {
+						h.add(i)
+					}
+				}
+
+				// Simulate stable traffic while at high traffic, moving towards the front
+				h.phase("high-stable")
+				for i := 0; i < count; i++ {
+					h.pushFront(i)
+					h.popBack()
+				}
+
+				// Simulate going back to normal (DDOS attack fended off), removing from both ends
+				h.phase("recovery")
+				for i := 0; i < count; i++ {
+					if i%2 == 0 {
+						h.popBack()
+					} else {
+						h.remove()
+					}
+				}
+
+				// Simulate stable traffic (now that is back to normal)
+				h.phase("normal")
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.remove()
+				}
+				h.phase("")
+			}
+		})
+	}
+}
+
+// dequeHarness returns a harness that runs the operations against the deque. The harness add and remove
+// operations add to the back and remove from the front of the deque, respectively.
+func (t *TypedTests[T]) dequeHarness(b *testing.B, initInstance func(), pushFront func(v T), pushBack func(v T), popFront func() (T, bool), popBack func() (T, bool), empty func() bool) *harness[T] {
+	h := t.harness(b, initInstance, pushBack, popFront, empty)
+	h.pushFrontFn, h.popBackFn = pushFront, popBack
+	if h.model != nil {
+		// Deques are validated against a deque model, regardless of the configured order.
+		h.model = &dequeModel{}
+	}
+	return h
+}
@@ -0,0 +1,329 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"flag"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// instance holds the operations run against a counting data structure instance, from the init call that
+// created it to the next one.
+type instance struct {
+	adds, removes, misses, empties, peeks, updates int
+
+	// fronts is the number of items added to the front and backs the number of items removed from the back.
+	fronts, backs int
+
+	// len is the number of items the instance holds and max the most items it held.
+	len, max int
+
+	// order is a hash of the items removed from the instance, in the order they were removed.
+	order uint64
+}
+
+// fault is a way a broken counting data structure misbehaves.
+type fault int
+
+const (
+	// noFault is a counting data structure that works as documented.
+	noFault fault = iota
+
+	// wrongOrder removes the item next to the one Remove and PopBack must remove, if there is one.
+	wrongOrder
+
+	// phantomRemove returns ok=true when removing from an empty data structure.
+	phantomRemove
+
+	// lostRemove returns ok=false when removing from a data structure holding items.
+	lostRemove
+
+	// lyingEmpty returns false when the data structure is empty.
+	lyingEmpty
+
+	// lyingLen returns one item more than the data structure holds.
+	lyingLen
+
+	// wrongPeek peeks the item next to the one Remove would remove, if there is one.
+	wrongPeek
+
+	// lostPeek returns ok=false when peeking a data structure holding items.
+	lostPeek
+
+	// hoarding keeps the removed items, and 1KB of memory for each one, until the next init call.
+	hoarding
+
+	// duplicateRemove returns the item without removing it on every second remove of an item.
+	duplicateRemove
+)
+
+// counting is a data structure that counts the operations run against it. counting is a deque and a min
+// priority queue, with the items of the same priority removed in the order they were added, so it can be
+// tested by every suite. counting is safe for concurrent use.
+type counting[T any] struct {
+	mu         sync.Mutex
+	items      []T
+	priorities []int
+	count      func(v T) int
+	fault      fault
+
+	// hoard holds the removed items, and the memory allocated for each one, kept by the hoarding fault.
+	hoard []interface{}
+
+	// removals is the number of items removed, counted by the duplicateRemove fault.
+	removals int
+
+	// instances holds the operations run against each instance, in the order they were initialized.
+	instances []*instance
+}
+
+// newCounting returns a counting data structure holding values of type T.
+func newCounting[T any]() *counting[T] {
+	return &counting[T]{count: (&TypedTests[T]{}).count()}
+}
+
+// newBroken returns a counting data structure holding values of type T that misbehaves with the fault.
+func newBroken[T any](f fault) *counting[T] {
+	c := newCounting[T]()
+	c.fault = f
+	return c
+}
+
+// Init initializes a new instance.
+func (c *counting[T]) Init() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items, c.priorities, c.hoard = nil, nil, nil
+	c.instances = append(c.instances, &instance{})
+}
+
+// current returns the instance in use.
+func (c *counting[T]) current() *instance {
+	if len(c.instances) == 0 {
+		panic("counting: init was not called")
+	}
+	return c.instances[len(c.instances)-1]
+}
+
+// Add adds v to the back.
+func (c *counting[T]) Add(v T) {
+	c.Push(0, v)
+}
+
+// PushFront adds v to the front.
+func (c *counting[T]) PushFront(v T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append([]T{v}, c.items...)
+	c.priorities = append([]int{0}, c.priorities...)
+	c.added().fronts++
+}
+
+// Push adds v with the priority.
+func (c *counting[T]) Push(priority int, v T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, v)
+	c.priorities = append(c.priorities, priority)
+	c.added()
+}
+
+// added counts the added item.
+func (c *counting[T]) added() *instance {
+	i := c.current()
+	i.adds++
+	i.len++
+	if i.len > i.max {
+		i.max = i.len
+	}
+	return i
+}
+
+// Remove removes the item with the lowest priority, the front item if all items have the same priority.
+func (c *counting[T]) Remove() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := 0
+	for j, p := range c.priorities {
+		if p < c.priorities[k] {
+			k = j
+		}
+	}
+	if c.fault == wrongOrder && k+1 < len(c.items) {
+		k++
+	}
+	return c.remove(k)
+}
+
+// PopBack removes the back item.
+func (c *counting[T]) PopBack() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := len(c.items) - 1
+	if c.fault == wrongOrder && k > 0 {
+		k--
+	}
+	v, ok := c.remove(k)
+	if ok {
+		c.current().backs++
+	}
+	return v, ok
+}
+
+// remove removes the k-th item, if any.
+func (c *counting[T]) remove(k int) (T, bool) {
+	var zero T
+	i := c.current()
+	if len(c.items) == 0 {
+		i.misses++
+		return zero, c.fault == phantomRemove
+	}
+	if c.fault == lostRemove {
+		return zero, false
+	}
+	v := c.items[k]
+	c.removals++
+	if c.fault == duplicateRemove && c.removals%2 == 0 {
+		return v, true
+	}
+	if c.fault == hoarding {
+		c.hoard = append(c.hoard, v, make([]byte, 1024))
+	}
+	copy(c.items[k:], c.items[k+1:])
+	copy(c.priorities[k:], c.priorities[k+1:])
+	c.items[len(c.items)-1] = zero
+	c.items, c.priorities = c.items[:len(c.items)-1], c.priorities[:len(c.priorities)-1]
+	i.removes++
+	i.len--
+	i.order = i.order*31 + uint64(c.count(v)+1)
+	return v, true
+}
+
+// Empty returns whether the instance holds no items.
+func (c *counting[T]) Empty() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current().empties++
+	return len(c.items) == 0 && c.fault != lyingEmpty
+}
+
+// Len returns the number of items in the instance.
+func (c *counting[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fault == lyingLen {
+		return len(c.items) + 1
+	}
+	return len(c.items)
+}
+
+// Peek returns the item Remove would remove, without removing it.
+func (c *counting[T]) Peek() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current().peeks++
+	var zero T
+	if len(c.items) == 0 || c.fault == lostPeek {
+		return zero, false
+	}
+	k := 0
+	for j, p := range c.priorities {
+		if p < c.priorities[k] {
+			k = j
+		}
+	}
+	if c.fault == wrongPeek && k+1 < len(c.items) {
+		k++
+	}
+	return c.items[k], true
+}
+
+// Update changes the priority of v.
+func (c *counting[T]) Update(v T, priority int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current().updates++
+	for j, x := range c.items {
+		if any(x) == any(v) {
+			c.priorities[j] = priority
+			return
+		}
+	}
+	panic("counting: updated item not found")
+}
+
+// runBenchmark runs f once with b.N set to 1 for each of its sub-benchmarks, failing the test if any of them fails.
+func runBenchmark(t *testing.T, f func(b *testing.B)) {
+	t.Helper()
+	runBenchmarkN(t, 1, f)
+}
+
+// runBenchmarkN runs f once with b.N set to n for each of its sub-benchmarks, failing the test if any of them fails.
+func runBenchmarkN(t *testing.T, n int, f func(b *testing.B)) {
+	t.Helper()
+	if benchmarkFailsN(t, n, f) {
+		t.Fatal("the benchmark failed")
+	}
+}
+
+// benchmarkFails runs f once with b.N set to 1 for each of its sub-benchmarks, and returns whether any of them
+// failed.
+func benchmarkFails(t *testing.T, f func(b *testing.B)) bool {
+	t.Helper()
+	return benchmarkFailsN(t, 1, f)
+}
+
+var (
+	// benchtimeMu serializes the benchmarks run by the tests, as each one sets the -test.benchtime flag it runs
+	// with, and benchtime is the flag value before the tests first set it.
+	benchtimeMu    sync.Mutex
+	benchtime      string
+	benchtimeSaved bool
+)
+
+// benchmarkFailsN runs f once with b.N set to n for each of its sub-benchmarks, and returns whether any of them
+// failed. The -test.benchtime flag is restored once the test completes.
+func benchmarkFailsN(t *testing.T, n int, f func(b *testing.B)) bool {
+	t.Helper()
+	benchtimeMu.Lock()
+	defer benchtimeMu.Unlock()
+	if !benchtimeSaved {
+		benchtime, benchtimeSaved = flag.Lookup("test.benchtime").Value.String(), true
+	}
+	t.Cleanup(func() {
+		benchtimeMu.Lock()
+		defer benchtimeMu.Unlock()
+		flag.Set("test.benchtime", benchtime)
+	})
+	if err := flag.Set("test.benchtime", strconv.Itoa(n)+"x"); err != nil {
+		t.Fatal(err)
+	}
+	failed := false
+	testing.Benchmark(func(b *testing.B) {
+		defer func() { failed = b.Failed() }()
+		f(b)
+	})
+	return failed
+}
@@ -22,44 +22,38 @@
 // and efficiency of data structures.
 package benchmark
 
-import (
-	"strconv"
-	"testing"
-)
+import "testing"
 
 // Fill test the data structures performance by sequentially adding n items to the data structure and then removing all added items.
 // Fill tests the data structures ability for quickly expand and shrink.
 func (t *Tests) Fill(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
-	for _, test := range tests {
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
-			for n := 0; n < b.N; n++ {
-				initInstance()
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-				}
-				for !empty() {
-					tmp, tmp2 = remove()
-				}
-			}
-		})
-	}
+	t.untyped().Fill(b, initInstance, add, remove, empty)
 }
 
 // FillTestObject test the data structures performance by sequentially adding n items to the data structure and then removing all added items.
 // FillTestObject tests the data structures ability for quickly expand and shrink.
-// FillTestObject is a copy of Fill that operates on *TestValue object which allows data structures that suport
+// FillTestObject is a version of Fill that operates on *TestValue object which allows data structures that suport
 // generics to not need to perform any type cast in the benchmark tests.
 func (t *Tests) FillTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
-	for _, test := range tests {
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
+	t.testObject().Fill(b, initInstance, add, remove, empty)
+}
+
+// Fill test the data structures performance by sequentially adding n items to the data structure and then removing all added items.
+// Fill tests the data structures ability for quickly expand and shrink.
+func (t *TypedTests[T]) Fill(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	for _, count := range t.sizes("Fill") {
+		h.run(count, func(b *testing.B) {
 			for n := 0; n < b.N; n++ {
-				initInstance()
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
+				h.init()
+				for i := 0; i < count; i++ {
+					h.add(i)
 				}
-				for !empty() {
-					tmp, tmp2 = remove()
+				h.footprint()
+				for !h.empty() {
+					h.remove()
 				}
+				h.footprint()
 			}
 		})
 	}
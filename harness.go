@@ -0,0 +1,528 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// harness runs the data structure operations on behalf of the test suites.
+// harness tracks the number of items in the data structure and fails the benchmark when remove or empty
+// disagree with it. harness also validates the removed values against a reference model when validation
+// is enabled.
+type harness[T any] struct {
+	// b is the benchmark currently running.
+	b *testing.B
+
+	initInstance func()
+	addFn        func(v T)
+	removeFn     func() (T, bool)
+	emptyFn      func() bool
+
+	// pushFrontFn and popBackFn are the deque functions that operate on the other end of the
+	// data structure; nil if not testing a deque.
+	pushFrontFn func(v T)
+	popBackFn   func() (T, bool)
+
+	// peekFn is the peek function; nil if not testing peeks.
+	peekFn func() (T, bool)
+
+	// lenFn is the optional Config.Len function; nil if not set.
+	lenFn func() int
+
+	// pushFn is the priority queue push function; nil if not testing a priority queue.
+	pushFn func(priority int, v T)
+
+	// priority returns the priority of the i-th value pushed to the priority queue.
+	priority func(i int) int
+
+	// updateFn is the priority queue update function; nil if not testing priority updates.
+	updateFn func(v T, priority int)
+
+	// seq is the number of values pushed to the priority queue since it was last initialized.
+	seq int
+
+	// pushed holds the values pushed to the priority queue, so they can be updated; nil if not
+	// testing priority updates.
+	pushed []T
+
+	value func(i int) T
+
+	// pool holds the values built before running the tests and build builds the values not in the pool;
+	// build is nil if the value pool is disabled.
+	pool  []T
+	build func(i int) T
+
+	// unpooled is the number of values in the data structure built outside of the pool, and poolIndex returns
+	// the index the values were built with, so they can be told apart; nil if the memory metrics or the value
+	// pool are disabled, or if Config.Value is set without Config.Count, in which case all values are assumed
+	// to be pooled.
+	unpooled  int
+	poolIndex func(v T) int
+
+	// model is the reference model used to validate the removed values; nil if validation is disabled.
+	model orderModel
+
+	// key returns the key the removed values are validated by, named keyName in the error messages.
+	key     func(v T) int
+	keyName string
+
+	// ops is the number of add and remove operations run since the data structure was last initialized.
+	ops int
+
+	// len is the number of items expected to be in the data structure.
+	len int
+
+	// phases measures each phase of the tests; nil if the phase metrics are disabled.
+	phases *phaseMetrics
+
+	// latencies measures the latency of each add and remove; nil if the latencies are disabled.
+	latencies *latencies
+
+	// memory measures the heap held by the data structure; nil if the memory metrics are disabled.
+	memory *memoryMetrics
+
+	// overhead sets whether each test is also run against a null data structure to measure the harness overhead.
+	overhead bool
+
+	// Used to store temp values, avoiding any compiler optimizations.
+	tmp  T
+	tmp2 bool
+}
+
+// harness returns a harness that runs the operations against the data structure.
+func (t *TypedTests[T]) harness(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) *harness[T] {
+	h := &harness[T]{
+		b:            b,
+		initInstance: initInstance,
+		addFn:        add,
+		removeFn:     remove,
+		emptyFn:      empty,
+		lenFn:        t.Len,
+		value:        t.value(),
+		overhead:     t.Overhead,
+	}
+	if t.Validate != NoValidation {
+		h.model = newOrderModel(t.Validate)
+		h.key, h.keyName = t.count(), "item"
+	}
+	if t.PhaseMetrics {
+		h.phases = newPhaseMetrics()
+	}
+	if t.Latencies || t.WorstOps > 0 {
+		h.latencies = newLatencies(t.Latencies, t.WorstOps, t.Cap)
+	}
+	if t.ValuePool {
+		h.build, h.value = h.value, h.pooled
+	}
+	if t.Memory || t.ShrinkLimits != nil {
+		h.memory = newMemoryMetrics(t.ShrinkLimits)
+		if t.ValuePool && (t.Value == nil || t.Count != nil) {
+			h.poolIndex = t.count()
+		}
+	}
+	return h
+}
+
+// run runs f as a sub-benchmark named after the number of items in the test.
+// The values of the items are built before running f, and not included in the results, if the value pool is enabled.
+// f is first run against a null data structure, if the overhead is measured.
+func (h *harness[T]) run(count int, f func(b *testing.B)) {
+	if h.overhead {
+		h.runNull(count, f)
+	}
+	parent := h.b
+	parent.Run(strconv.Itoa(count), func(b *testing.B) {
+		h.b = b
+		defer func() { h.b = parent }()
+		h.latencies.reset()
+		if h.memory != nil {
+			h.memory.reset()
+		}
+		if h.build != nil {
+			h.reserve(count)
+			b.ResetTimer()
+		}
+		f(b)
+		if h.phases != nil {
+			h.phases.report(b)
+		}
+		if h.memory != nil {
+			h.memory.report(b)
+		}
+		h.latencies.report(b)
+	})
+}
+
+// phase ends the running test phase, if any, and starts the named phase when the phase metrics are
+// enabled. An empty name ends the running phase. The phases allocations are read with the benchmark
+// timer stopped, but reading the clock and stopping and starting the timer are included in the benchmark
+// results. The memory footprint is measured at the start of each phase, after the running phase ended, so
+// the time spent measuring it is not included in any phase.
+func (h *harness[T]) phase(name string) {
+	h.latencies.setPhase(name)
+	if h.phases == nil {
+		h.footprint()
+		return
+	}
+	end := time.Now()
+	h.b.StopTimer()
+	h.measureFootprint()
+	h.phases.next(name, end)
+	h.b.StartTimer()
+	h.phases.start = time.Now()
+}
+
+// reserve builds the first n values into the pool, if they were not built yet and the value pool is enabled.
+func (h *harness[T]) reserve(n int) {
+	if h.build == nil {
+		return
+	}
+	for i := len(h.pool); i < n; i++ {
+		h.pool = append(h.pool, h.build(i))
+	}
+}
+
+// pooled returns the i-th value from the pool, building it if it is not in the pool.
+func (h *harness[T]) pooled(i int) T {
+	if i < len(h.pool) {
+		return h.pool[i]
+	}
+	return h.build(i)
+}
+
+// init initializes a new data structure instance.
+func (h *harness[T]) init() {
+	h.initInstance()
+	if h.memory != nil {
+		h.memory.draining = false
+		if h.memory.stale {
+			h.b.StopTimer()
+			h.baseline()
+			h.b.StartTimer()
+		}
+	}
+	h.ops = 0
+	h.len = 0
+	h.seq = 0
+	h.unpooled = 0
+	if h.pushed != nil {
+		h.pushed = h.pushed[:0]
+	}
+	if h.model != nil {
+		h.model.reset()
+	}
+}
+
+// prepare initializes a new data structure instance and adds fill items to it. The time spent preparing the
+// data structure is not included in the benchmark results, so the suites call prepare at the start of each
+// run of a test, which always runs against an identically prepared instance regardless of the previous tests.
+func (h *harness[T]) prepare(fill int) {
+	h.init()
+	h.fillTo(fill)
+	h.b.ResetTimer()
+}
+
+// fillTo adds items to the data structure until it holds n items. The latencies of the adds are not tracked,
+// as the suites call fillTo to prepare the data structure outside of the timed region.
+func (h *harness[T]) fillTo(n int) {
+	h.untracked(func() {
+		for i := h.len; i < n; i++ {
+			h.add(i)
+		}
+	})
+}
+
+// preparePriorityQueue initializes a new priority queue instance and pushes fill items to it, as prepare does.
+func (h *harness[T]) preparePriorityQueue(fill int) {
+	h.init()
+	h.untracked(func() {
+		for h.len < fill {
+			h.push()
+		}
+	})
+	h.b.ResetTimer()
+}
+
+// untracked runs f without tracking the latencies of the operations it runs, as the suites run f to prepare
+// the data structure outside of the timed region.
+func (h *harness[T]) untracked(f func()) {
+	l := h.latencies
+	h.latencies = nil
+	defer func() { h.latencies = l }()
+	f()
+}
+
+// add adds the i-th value to the data structure.
+func (h *harness[T]) add(i int) {
+	v := h.value(i)
+	start := h.latencies.begin()
+	h.addFn(v)
+	h.latencies.added(start, h.len, h.ops+1)
+	h.added(v, false)
+}
+
+// pushFront adds the i-th value to the front of the deque.
+func (h *harness[T]) pushFront(i int) {
+	v := h.value(i)
+	start := h.latencies.begin()
+	h.pushFrontFn(v)
+	h.latencies.added(start, h.len, h.ops+1)
+	h.added(v, true)
+}
+
+// added tracks the value added to the data structure.
+func (h *harness[T]) added(v T, front bool) {
+	h.ops++
+	h.len++
+	h.tracked(v, 1)
+	if h.model != nil {
+		if front {
+			h.model.(*dequeModel).addFront(h.key(v))
+		} else {
+			h.model.add(h.key(v))
+		}
+	}
+}
+
+// push adds the next value to the priority queue with its priority.
+func (h *harness[T]) push() {
+	i := h.seq
+	h.seq++
+	p := h.priority(i)
+	v := h.value(i)
+	start := h.latencies.begin()
+	h.pushFn(p, v)
+	h.latencies.added(start, h.len, h.ops+1)
+	h.ops++
+	h.len++
+	h.tracked(v, 1)
+	if h.pushed != nil {
+		h.pushed = append(h.pushed, v)
+	}
+	if h.model != nil {
+		h.model.add(p)
+	}
+}
+
+// update changes the priority of the i-th value pushed to the priority queue.
+func (h *harness[T]) update(i, priority int) {
+	h.updateFn(h.pushed[i], priority)
+	h.ops++
+}
+
+// reprioritize sets the priorities of the values in the priority queue, after they were all updated,
+// so the removed values are validated by their new priorities.
+func (h *harness[T]) reprioritize(priority func(i int) int) {
+	h.priority = priority
+	if h.model == nil {
+		return
+	}
+	h.model.reset()
+	for i := 0; i < h.seq; i++ {
+		h.model.add(priority(i))
+	}
+}
+
+// remove removes an item from the data structure.
+func (h *harness[T]) remove() {
+	start := h.latencies.begin()
+	h.tmp, h.tmp2 = h.removeFn()
+	h.latencies.removed(start, h.len, h.ops+1)
+	h.removed(false)
+}
+
+// popBack removes an item from the back of the deque.
+func (h *harness[T]) popBack() {
+	start := h.latencies.begin()
+	h.tmp, h.tmp2 = h.popBackFn()
+	h.latencies.removed(start, h.len, h.ops+1)
+	h.removed(true)
+}
+
+// removed checks the result of the last remove call.
+func (h *harness[T]) removed(back bool) {
+	h.ops++
+	if !h.tmp2 {
+		if h.len > 0 {
+			h.b.Fatalf("operation %d: remove returned ok=false, want an item as the data structure has %d items", h.ops, h.len)
+		}
+		return
+	}
+	if h.len == 0 {
+		h.b.Fatalf("operation %d: remove returned ok=true, want ok=false as the data structure is empty", h.ops)
+	}
+	h.len--
+	h.tracked(h.tmp, -1)
+	if h.model != nil {
+		h.validate(h.tmp, back)
+	}
+}
+
+// tracked counts the value added to (delta 1) or removed from (delta -1) the data structure if it was built
+// outside of the pool, when the memory metrics and the value pool are enabled. The pooled values are measured
+// with the baseline heap, so only the other values are not included in the memory footprint.
+func (h *harness[T]) tracked(v T, delta int) {
+	if h.poolIndex == nil {
+		return
+	}
+	if i := h.poolIndex(v); i < 0 || i >= len(h.pool) {
+		h.unpooled += delta
+	}
+}
+
+// empty returns whether the data structure is empty.
+func (h *harness[T]) empty() bool {
+	e := h.emptyFn()
+	if e != (h.len == 0) {
+		h.b.Fatalf("operation %d: empty returned %t, want %t as the data structure has %d items", h.ops, e, !e, h.len)
+	}
+	return e
+}
+
+// peek returns the next item to be removed from the data structure without removing it.
+func (h *harness[T]) peek() {
+	h.tmp, h.tmp2 = h.peekFn()
+	h.ops++
+	if h.tmp2 != (h.len > 0) {
+		h.b.Fatalf("operation %d: peek returned ok=%t, want ok=%t as the data structure has %d items", h.ops, h.tmp2, !h.tmp2, h.len)
+	}
+	if h.model == nil || !h.tmp2 {
+		return
+	}
+	want, _ := h.model.peek()
+	if got := h.key(h.tmp); got != want {
+		h.b.Fatalf("operation %d: peek returned %s %d, want %s %d (%v)", h.ops, h.keyName, got, h.keyName, want, h.model)
+	}
+}
+
+// length returns the number of items in the data structure using Config.Len.
+func (h *harness[T]) length() int {
+	l := h.lenFn()
+	if l != h.len {
+		h.b.Fatalf("operation %d: len returned %d, want %d", h.ops, l, h.len)
+	}
+	return l
+}
+
+// trend checks the data structure holds delta items more than the l items it held before a loop of a test
+// that must strictly increase or decrease the number of items, i.e. SlowIncrease.
+func (h *harness[T]) trend(l, delta int) {
+	if h.len != l+delta {
+		h.b.Fatalf("operation %d: the data structure went from %d to %d items, want %d", h.ops, l, h.len, l+delta)
+	}
+}
+
+// checkLen checks the data structure holds the number of items expected by the test, using Config.Len, if set.
+// The suites call checkLen once, at the end of each run, and the number of items is checked outside of the
+// timed region.
+func (h *harness[T]) checkLen() {
+	if h.lenFn == nil {
+		return
+	}
+	h.b.StopTimer()
+	h.length()
+	h.b.StartTimer()
+}
+
+// validate checks the removed value against the reference model.
+func (h *harness[T]) validate(v T, back bool) {
+	var want int
+	var ok bool
+	if back {
+		want, ok = h.model.(*dequeModel).removeBack()
+	} else {
+		want, ok = h.model.remove()
+	}
+	if !ok {
+		return
+	}
+	if got := h.key(v); got != want {
+		h.b.Fatalf("operation %d: remove returned %s %d, want %s %d (%v)", h.ops, h.keyName, got, h.keyName, want, h.model)
+	}
+}
+
+// baseline measures the heap held right after the data structure was initialized, which the memory footprint
+// is measured against. The heap held by each value is measured the first time, building new values even if
+// the value pool is enabled. The pooled values are included in the baseline heap.
+func (h *harness[T]) baseline() {
+	m := h.memory
+	if !m.calibrated {
+		build := h.value
+		if h.build != nil {
+			build = h.build
+		}
+		values := make([]T, memoryCalibrationValues)
+		before := m.heap()
+		for i := range values {
+			values[i] = build(i)
+		}
+		after := m.heap()
+		runtime.KeepAlive(values)
+		if after > before {
+			m.valueBytes = float64(after-before) / memoryCalibrationValues
+		}
+		m.calibrated = true
+	}
+	m.baseline = m.heap()
+	m.stale = false
+}
+
+// footprint measures the heap held by the data structure, if the memory metrics are enabled, when it holds
+// more items than in any previous measure of the run, or when the same instance was first drained after the
+// last such measure.
+// The heap held by the values in the data structure is not included. Measuring the heap requires a garbage collection, so the suites call footprint where
+// the data structure holds the most items and where it was drained, and the time spent measuring it is
+// not included in the benchmark results.
+func (h *harness[T]) footprint() {
+	if h.memory == nil || !h.memory.due(h.len) {
+		return
+	}
+	h.b.StopTimer()
+	h.measureFootprint()
+	h.b.StartTimer()
+}
+
+// measureFootprint measures the heap held by the data structure, as footprint does, with the benchmark timer
+// already stopped.
+func (h *harness[T]) measureFootprint() {
+	m := h.memory
+	if m == nil {
+		return
+	}
+	switch {
+	case h.len > m.peakLen:
+		values := h.len
+		if h.build != nil {
+			values = h.unpooled
+		}
+		m.peakLen, m.peak = h.len, m.held(int64(float64(values)*m.valueBytes))
+		m.draining = true
+	case h.len == 0 && m.draining:
+		m.retained, m.drained = m.held(0), true
+		m.draining = false
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"bytes"
+	"container/list"
+	"testing"
+)
+
+func TestConsistencyChecks(t *testing.T) {
+	// The trace removes from an empty data structure, which no suite does.
+	var trace bytes.Buffer
+	r, err := NewRecorder[interface{}](&trace, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := newCounting[interface{}]()
+	r.Init(recorded.Init)()
+	r.Add(recorded.Add)(1)
+	r.Remove(recorded.Remove)()
+	r.Remove(recorded.Remove)()
+	r.Empty(recorded.Empty)()
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fill := func(tests *Tests, b *testing.B, c *counting[interface{}]) {
+		tests.Fill(b, c.Init, c.Add, c.Remove, c.Empty)
+	}
+	slowIncrease := func(tests *Tests, b *testing.B, c *counting[interface{}]) {
+		tests.Len = c.Len
+		tests.SlowIncrease(b, c.Init, c.Add, c.Remove, c.Empty)
+	}
+	slowDecrease := func(tests *Tests, b *testing.B, c *counting[interface{}]) {
+		tests.Len = c.Len
+		tests.SlowDecrease(b, c.Init, c.Add, c.Remove, c.Empty)
+	}
+	replay := func(tests *Tests, b *testing.B, c *counting[interface{}]) {
+		tests.Replay(b, bytes.NewReader(trace.Bytes()), c.Init, c.Add, c.Remove, c.Empty)
+	}
+	tests := []struct {
+		name  string
+		run   func(tests *Tests, b *testing.B, c *counting[interface{}])
+		fault fault
+		fails bool
+	}{
+		{name: "Fill", run: fill},
+		{name: "LostRemove", run: fill, fault: lostRemove, fails: true},
+		{name: "LyingEmpty", run: fill, fault: lyingEmpty, fails: true},
+		{name: "SlowIncrease", run: slowIncrease},
+		{name: "SlowIncreaseLyingLen", run: slowIncrease, fault: lyingLen, fails: true},
+		{name: "SlowDecrease", run: slowDecrease},
+		{name: "SlowDecreaseLyingLen", run: slowDecrease, fault: lyingLen, fails: true},
+		{name: "Replay", run: replay},
+		{name: "PhantomRemove", run: replay, fault: phantomRemove, fails: true},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			config := testConfig()
+			config.Sizes = []int{7}
+			c := newBroken[interface{}](test.fault)
+			if got := benchmarkFails(t, func(b *testing.B) { test.run(&Tests{Config: config}, b, c) }); got != test.fails {
+				t.Errorf("got failed=%t, want %t", got, test.fails)
+			}
+		})
+	}
+}
+
+func TestValuePool(t *testing.T) {
+	for _, pool := range []bool{false, true} {
+		builds := 0
+		tests := &TypedTests[*TestValue]{
+			Config: testConfig(),
+			Value: func(i int) *TestValue {
+				builds++
+				return GetTestValue(i)
+			},
+		}
+		tests.Sizes, tests.ValuePool = []int{5, 5, 3}, pool
+		c := newCounting[*TestValue]()
+		var added []*TestValue
+		add := func(v *TestValue) {
+			added = append(added, v)
+			c.Add(v)
+		}
+		runBenchmark(t, func(b *testing.B) { tests.Refill(b, c.Init, add, c.Remove, c.Empty) })
+
+		if len(added) != testRefills*13 {
+			t.Fatalf("pool=%t: added %d values, want %d", pool, len(added), testRefills*13)
+		}
+		if !pool {
+			if builds != len(added) {
+				t.Errorf("pool=false: built %d values, want one per add (%d)", builds, len(added))
+			}
+			continue
+		}
+		// The values are built once and reused by every refill and every size.
+		if builds != 5 {
+			t.Errorf("pool=true: built %d values, want 5", builds)
+		}
+		for k, v := range added {
+			if want := added[k%5]; k < 2*testRefills*5 && v != want {
+				t.Fatalf("pool=true: add %d added a different value than add %d", k, k%5)
+			}
+		}
+	}
+}
+
+func TestValuePoolMemory(t *testing.T) {
+	const n = 4096
+	var l *list.List
+	peak := map[bool]float64{}
+	for _, pool := range []bool{false, true} {
+		tests := &TypedTests[*TestValue]{Config: Config{Memory: true, ValuePool: pool}}
+		runBenchmark(t, func(b *testing.B) {
+			h := tests.harness(b, func() { l = list.New() }, func(v *TestValue) { l.PushBack(v) }, nil, nil)
+			// The data structure holds both pooled values and values built outside of the pool.
+			h.run(n, func(b *testing.B) {
+				for k := 0; k < b.N; k++ {
+					h.init()
+					for i := 0; i < n; i++ {
+						h.add(i)
+						h.add(n + i)
+					}
+					h.footprint()
+				}
+			})
+			peak[pool] = float64(h.memory.peak) / float64(h.memory.peakLen)
+		})
+	}
+	// A list element takes 48 bytes; the measures may differ by a few bytes per item due to the other
+	// allocations.
+	if diff := peak[true] - peak[false]; diff < -2 || diff > 2 {
+		t.Fatalf("got %.2f peak bytes per item with the value pool, want %.2f as without it", peak[true], peak[false])
+	}
+}
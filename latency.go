@@ -0,0 +1,266 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"math/bits"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// histogramSubBuckets is the number of buckets each power of two range of a histogram is split into.
+	// 32 buckets give the recorded values a precision of about 3%.
+	histogramSubBuckets = 32
+
+	// histogramExact is the number of values, starting at 0, recorded with exact precision.
+	histogramExact = 2 * histogramSubBuckets
+
+	// histogramBuckets is the number of buckets needed to record any int64 value.
+	histogramBuckets = histogramExact + (64-7)*histogramSubBuckets
+
+	// calibrationSamples is the number of clock reads used to calibrate the timer overhead.
+	calibrationSamples = 10000
+)
+
+// percentiles are the latency percentiles reported when Config.Latencies is set.
+var percentiles = []struct {
+	name string
+	q    float64
+}{
+	{"p50", 0.5},
+	{"p90", 0.9},
+	{"p99", 0.99},
+	{"p99.9", 0.999},
+}
+
+var (
+	// timerOverhead is the measured time it takes to time an operation that does nothing.
+	timerOverhead     time.Duration
+	timerOverheadOnce sync.Once
+)
+
+// histogram is a HDR style histogram of latencies: values are recorded in buckets whose width grows with
+// the values, so every value is recorded with the same relative precision using a fixed amount of memory.
+type histogram struct {
+	counts [histogramBuckets]uint64
+	count  uint64
+	max    int64
+}
+
+// bucket returns the bucket the value is recorded in. Values smaller than histogramExact have their own
+// bucket. Larger values are recorded in one of the histogramSubBuckets buckets of their power of two range.
+func (h *histogram) bucket(v int64) int {
+	if v < histogramExact {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - 6
+	return histogramExact + (shift-1)*histogramSubBuckets + int(v>>shift) - histogramSubBuckets
+}
+
+// highest returns the highest value recorded in the bucket.
+func (h *histogram) highest(bucket int) int64 {
+	if bucket < histogramExact {
+		return int64(bucket)
+	}
+	shift := (bucket-histogramExact)/histogramSubBuckets + 1
+	m := int64((bucket-histogramExact)%histogramSubBuckets + histogramSubBuckets)
+	return (m+1)<<shift - 1
+}
+
+// record records the value.
+func (h *histogram) record(v int64) {
+	if v < 0 {
+		v = 0
+	}
+	h.counts[h.bucket(v)]++
+	h.count++
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// percentile returns the value below which the fraction q of the recorded values are.
+func (h *histogram) percentile(q float64) int64 {
+	rank := uint64(q*float64(h.count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var n uint64
+	for b, c := range h.counts {
+		n += c
+		if n >= rank {
+			if v := h.highest(b); v < h.max {
+				return v
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+// reset removes all recorded values.
+func (h *histogram) reset() {
+	*h = histogram{}
+}
+
+// latencies measures the latency of the add and remove operations of a test, recording them in histograms
+// (Config.Latencies) and tracking the slowest operations (Config.WorstOps). The latencies methods do nothing
+// on a nil latencies, so the harness can call them regardless of the config.
+type latencies struct {
+	// histograms is whether the latencies are recorded in the adds and removes histograms.
+	histograms    bool
+	adds, removes histogram
+
+	// worst tracks the slowest operations; nil if not tracking them.
+	worst *worstOps
+
+	// capFn is the optional Config.Cap function; nil if not set.
+	capFn func() int
+
+	// phase is the name of the running test phase, if any.
+	phase string
+
+	// overhead is the timer overhead subtracted from the measured latencies.
+	overhead int64
+}
+
+// newLatencies returns a latencies that records the latencies in histograms, if histograms is set, and tracks
+// the worst slowest operations, if worst is greater than 0.
+func newLatencies(histograms bool, worst int, capFn func() int) *latencies {
+	timerOverheadOnce.Do(calibrate)
+	l := &latencies{
+		histograms: histograms,
+		capFn:      capFn,
+		overhead:   timerOverhead.Nanoseconds(),
+	}
+	if worst > 0 {
+		l.worst = &worstOps{n: worst}
+	}
+	return l
+}
+
+// calibrate measures the timer overhead as the median time it takes to time an operation that does nothing.
+func calibrate() {
+	samples := make([]time.Duration, calibrationSamples)
+	for i := range samples {
+		start := time.Now()
+		samples[i] = time.Since(start)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	timerOverhead = samples[len(samples)/2]
+}
+
+// begin returns the time the operation started.
+func (l *latencies) begin() time.Time {
+	if l == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// added records the latency of an add that started at start. len is the number of items in the data structure
+// before the add and op is the operation number.
+func (l *latencies) added(start time.Time, len, op int) {
+	if l == nil {
+		return
+	}
+	l.record(OpAdd, &l.adds, start, len, op)
+}
+
+// removed records the latency of a remove that started at start. len is the number of items in the data structure
+// before the remove and op is the operation number.
+func (l *latencies) removed(start time.Time, len, op int) {
+	if l == nil {
+		return
+	}
+	l.record(OpRemove, &l.removes, start, len, op)
+}
+
+// record records the latency of an operation that started at start.
+func (l *latencies) record(kind OpKind, h *histogram, start time.Time, len, op int) {
+	ns := time.Since(start).Nanoseconds() - l.overhead
+	if ns < 0 {
+		ns = 0
+	}
+	if l.histograms {
+		h.record(ns)
+	}
+	if l.worst != nil && l.worst.slower(ns) {
+		c := -1
+		if l.capFn != nil {
+			c = l.capFn()
+		}
+		l.worst.add(worstOp{kind: kind, ns: ns, len: len, cap: c, op: op, phase: l.phase})
+	}
+}
+
+// setPhase sets the name of the running test phase.
+func (l *latencies) setPhase(name string) {
+	if l == nil {
+		return
+	}
+	l.phase = name
+}
+
+// reset removes all recorded latencies.
+func (l *latencies) reset() {
+	if l == nil {
+		return
+	}
+	l.adds.reset()
+	l.removes.reset()
+	l.worst.reset()
+	l.phase = ""
+}
+
+// report reports the latency percentiles and the maximum latency of the adds and removes, and the slowest
+// operations, and resets the histograms.
+func (l *latencies) report(b *testing.B) {
+	if l == nil {
+		return
+	}
+	l.worst.report(b)
+	if !l.histograms || l.adds.count+l.removes.count == 0 {
+		return
+	}
+	for _, h := range []struct {
+		name string
+		h    *histogram
+	}{
+		{"add", &l.adds},
+		{"remove", &l.removes},
+	} {
+		if h.h.count == 0 {
+			continue
+		}
+		for _, p := range percentiles {
+			b.ReportMetric(float64(h.h.percentile(p.q)), h.name+"-"+p.name+"-ns")
+		}
+		b.ReportMetric(float64(h.h.max), h.name+"-max-ns")
+		h.h.reset()
+	}
+	b.ReportMetric(float64(l.overhead), "timer-overhead-ns")
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// leakAttempts is the number of garbage collections the Leak test runs while waiting for the removed values
+// to be collected.
+const leakAttempts = 100
+
+// Leak checks the data structures don't keep references to the removed items by sequentially adding n items to the data
+// structure, removing all of them and then running garbage collections until all removed items were collected.
+// The items are *TestValue objects with a finalizer, so Leak knows when each of them is collected.
+// Leak fails, with the number of removed items that are still reachable, if any removed item is not collected. A data
+// structure that keeps references to removed items, i.e. a ring buffer that doesn't clear its slots after a remove,
+// keeps the items alive, holding memory for as long as the data structure lives.
+// Leak reports the number of items that were not collected (leaked). The results include the time spent running the
+// garbage collections, so they measure the cost of the check rather than the data structures performance.
+func (t *Tests) Leak(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().Leak(b, initInstance, add, remove, empty)
+}
+
+// LeakTestObject checks the data structures don't keep references to the removed items by sequentially adding n items to the
+// data structure, removing all of them and then running garbage collections until all removed items were collected.
+// LeakTestObject is a version of Leak that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) LeakTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
+	t.testObject().Leak(b, initInstance, add, remove, empty)
+}
+
+// Leak checks the data structures don't keep references to the removed items by sequentially adding n items to the data
+// structure, removing all of them and then running garbage collections until all removed items were collected.
+// The items are *TestValue objects with a finalizer, so Leak knows when each of them is collected, which requires T to be
+// *TestValue or interface{}. Leak ignores TypedTests.Value and is skipped for any other type.
+// Leak fails, with the number of removed items that are still reachable, if any removed item is not collected. A data
+// structure that keeps references to removed items, i.e. a ring buffer that doesn't clear its slots after a remove,
+// keeps the items alive, holding memory for as long as the data structure lives.
+// Leak reports the number of items that were not collected (leaked). The results include the time spent running the
+// garbage collections, so they measure the cost of the check rather than the data structures performance.
+func (t *TypedTests[T]) Leak(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	if _, ok := any(GetTestValue(0)).(T); !ok {
+		b.Skipf("Leak requires values of type *TestValue or interface{}, got %v", reflect.TypeOf((*T)(nil)).Elem())
+	}
+	h := t.harness(b, initInstance, add, remove, empty)
+
+	// added is the number of items added in the benchmark iteration and collected points to the number of
+	// them that were collected. Each iteration counts its items apart, so items of previous iterations
+	// collected late are not counted.
+	var added int64
+	var collected *int64
+	h.value = func(i int) T {
+		v, c := GetTestValue(i), collected
+		runtime.SetFinalizer(v, func(*TestValue) { atomic.AddInt64(c, 1) })
+		added++
+		return any(v).(T)
+	}
+	for _, count := range t.sizes("Leak") {
+		h.run(count, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				added, collected = 0, new(int64)
+				h.init()
+				for i := 0; i < count; i++ {
+					h.add(i)
+				}
+				for !h.empty() {
+					h.remove()
+				}
+
+				// The harness keeps the last removed value; it must not be counted as leaked.
+				var zero T
+				h.tmp = zero
+				for i := 0; atomic.LoadInt64(collected) < added && i < leakAttempts; i++ {
+					runtime.GC()
+					runtime.Gosched() // Lets the finalizers run.
+					if i > 0 {
+						time.Sleep(time.Millisecond)
+					}
+				}
+				if leaked := added - atomic.LoadInt64(collected); leaked > 0 {
+					b.ReportMetric(float64(leaked), "leaked")
+					b.Fatalf("%d of the %d removed items are still reachable after a garbage collection; the data structure keeps references to removed items", leaked, added)
+				}
+			}
+			b.ReportMetric(0, "leaked")
+		})
+	}
+}
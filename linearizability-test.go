@@ -0,0 +1,197 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Linearizability checks the data structures are linearizable queues or stacks by running producer goroutines that
+// concurrently add n items to a shared data structure instance while consumer goroutines concurrently remove them, recording
+// the call and return times of every operation. The recorded history is then checked against a sequential queue or stack,
+// as set in Config.Validate (FIFO or LIFO), and the benchmark fails with a minimal non-linearizable sub-history if the
+// check fails, i.e. if the data structure lost, duplicated or reordered items.
+// Linearizability runs with every combination of 1, 2 and 4 producers and consumers (Config.Producers and Config.Consumers).
+// Checking the histories is expensive, so Linearizability runs with up to 100 items by default. The results include the time
+// spent checking the histories, so they measure the cost of the check rather than the data structures performance.
+// Linearizability reports the average number of operations in the histories (history-ops).
+// The add and remove functions are called concurrently, so the data structure must be safe for concurrent use.
+func (t *Tests) Linearizability(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().Linearizability(b, initInstance, add, remove, empty)
+}
+
+// LinearizabilityTestObject checks the data structures are linearizable queues or stacks by running producer goroutines that
+// concurrently add n items to a shared data structure instance while consumer goroutines concurrently remove them, recording
+// the call and return times of every operation, and then checking the recorded history against a sequential queue or stack.
+// LinearizabilityTestObject is a version of Linearizability that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) LinearizabilityTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
+	t.testObject().Linearizability(b, initInstance, add, remove, empty)
+}
+
+// Linearizability checks the data structures are linearizable queues or stacks by running producer goroutines that
+// concurrently add n items to a shared data structure instance while consumer goroutines concurrently remove them, recording
+// the call and return times of every operation. The recorded history is then checked against a sequential queue or stack,
+// as set in Config.Validate (FIFO or LIFO), and the benchmark fails with a minimal non-linearizable sub-history if the
+// check fails, i.e. if the data structure lost, duplicated or reordered items.
+// Linearizability runs with every combination of 1, 2 and 4 producers and consumers (Config.Producers and Config.Consumers).
+// Checking the histories is expensive, so Linearizability runs with up to 100 items by default. The results include the time
+// spent checking the histories, so they measure the cost of the check rather than the data structures performance.
+// Linearizability reports the average number of operations in the histories (history-ops).
+// The add and remove functions are called concurrently, so the data structure must be safe for concurrent use.
+func (t *TypedTests[T]) Linearizability(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	if t.Validate != FIFO && t.Validate != LIFO {
+		b.Fatalf("Linearizability requires Config.Validate to be FIFO or LIFO, got %v", t.Validate)
+	}
+	t.goroutinesRun(b, func(b *testing.B, p, c int) {
+		h := t.harness(b, initInstance, add, remove, empty)
+		h.overhead = false // The data structure is called directly, not through the harness.
+		key := t.count()
+		for _, count := range t.sizes("Linearizability") {
+			h.run(count, func(b *testing.B) {
+				r := &historyRecorder[T]{
+					add:       add,
+					remove:    remove,
+					value:     h.value,
+					key:       key,
+					producers: p,
+					consumers: c,
+					count:     count,
+				}
+				ops := 0
+				for n := 0; n < b.N; n++ {
+					h.initInstance()
+					history := r.run()
+					if err := CheckLinearizable(history, t.Validate); err != nil {
+						b.Fatal(err)
+					}
+					ops += len(history)
+				}
+				b.ReportMetric(float64(ops)/float64(b.N), "history-ops")
+			})
+		}
+	})
+}
+
+// historyRecorder runs the producer and consumer goroutines of the Linearizability test, recording their operations.
+type historyRecorder[T any] struct {
+	add    func(v T)
+	remove func() (T, bool)
+	value  func(i int) T
+	key    func(v T) int
+
+	producers, consumers int
+
+	// count is the number of items added by the producers in each run.
+	count int
+
+	// producing is the number of producers still adding items.
+	producing int32
+
+	// start is the time the run started; the operations times are relative to start.
+	start time.Time
+
+	mu      sync.Mutex
+	history []HistoryOp
+}
+
+// run runs the producers and consumers once, starting them all at the same time, and returns the recorded history.
+// The consumers run until the data structure is empty and the producers are done.
+func (r *historyRecorder[T]) run() []HistoryOp {
+	r.producing = int32(r.producers)
+	r.history = nil
+
+	begin := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(r.producers + r.consumers)
+	for p := 0; p < r.producers; p++ {
+		from, to := p*r.count/r.producers, (p+1)*r.count/r.producers
+		go r.produce(begin, &wg, p, from, to)
+	}
+	for c := 0; c < r.consumers; c++ {
+		go r.consume(begin, &wg, r.producers+c)
+	}
+	r.start = time.Now()
+	close(begin)
+	wg.Wait()
+	return r.history
+}
+
+// produce adds the items from, inclusive, to to, exclusive, to the data structure.
+func (r *historyRecorder[T]) produce(begin <-chan struct{}, wg *sync.WaitGroup, g, from, to int) {
+	defer wg.Done()
+	<-begin
+	ops := make([]HistoryOp, 0, to-from)
+	for i := from; i < to; i++ {
+		v := r.value(i)
+		call := time.Since(r.start)
+		r.add(v)
+		ops = append(ops, HistoryOp{Goroutine: g, Kind: OpAdd, Item: i, Call: call, Return: time.Since(r.start)})
+	}
+	atomic.AddInt32(&r.producing, -1)
+	r.record(ops)
+}
+
+// consume removes items from the data structure until it's empty and the producers are done.
+// Only the last of consecutive removes that found the data structure empty is recorded, keeping the history
+// short. Dropping removes that found the data structure empty never turns a linearizable history into a
+// non-linearizable one, as they don't change the data structure.
+func (r *historyRecorder[T]) consume(begin <-chan struct{}, wg *sync.WaitGroup, g int) {
+	defer wg.Done()
+	<-begin
+	var ops []HistoryOp
+	for {
+		// The producers are checked before the remove, so a remove that finds the data structure empty
+		// after all producers are done means all items were removed.
+		done := atomic.LoadInt32(&r.producing) == 0
+		call := time.Since(r.start)
+		v, ok := r.remove()
+		op := HistoryOp{Goroutine: g, Kind: OpRemove, Item: -1, Ok: ok, Call: call, Return: time.Since(r.start)}
+		if ok {
+			op.Item = r.key(v)
+		}
+		if last := len(ops) - 1; !ok && last >= 0 && !ops[last].Ok {
+			ops[last] = op
+		} else {
+			ops = append(ops, op)
+		}
+		if !ok {
+			if done {
+				break
+			}
+			runtime.Gosched()
+		}
+	}
+	r.record(ops)
+}
+
+// record appends the operations of a goroutine to the history.
+func (r *historyRecorder[T]) record(ops []HistoryOp) {
+	r.mu.Lock()
+	r.history = append(r.history, ops...)
+	r.mu.Unlock()
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryOp is an add or remove operation of a concurrent history, i.e. the operations run by several
+// goroutines against a shared data structure instance.
+type HistoryOp struct {
+	// Goroutine identifies the goroutine that ran the operation.
+	Goroutine int
+
+	// Kind is the kind of the operation; either OpAdd or OpRemove.
+	Kind OpKind
+
+	// Item is the index of the added or removed item. Item is ignored for removes that found the
+	// data structure empty.
+	Item int
+
+	// Ok is the ok result of remove. Ok is ignored for adds.
+	Ok bool
+
+	// Call and Return are the times the operation was invoked and returned, relative to the start of
+	// the history.
+	Call, Return time.Duration
+}
+
+// String returns the operation in a human readable format, i.e. "goroutine 2: remove() = item 5 [1.2µs, 1.5µs]".
+func (op HistoryOp) String() string {
+	var call string
+	switch {
+	case op.Kind == OpAdd:
+		call = fmt.Sprintf("add(item %d)", op.Item)
+	case op.Kind == OpRemove && op.Ok:
+		call = fmt.Sprintf("remove() = item %d", op.Item)
+	case op.Kind == OpRemove:
+		call = "remove() = empty"
+	default:
+		call = op.Kind.String() + "()"
+	}
+	return fmt.Sprintf("goroutine %d: %s [%v, %v]", op.Goroutine, call, op.Call, op.Return)
+}
+
+// LinearizabilityError is the error returned by CheckLinearizable when the history is not linearizable.
+type LinearizabilityError struct {
+	// Order is the order the history was checked against.
+	Order Order
+
+	// History is a minimal non-linearizable sub-history of the checked history, sorted by call time.
+	// Removing any item, i.e. its add and its removes, or any remove that found the data structure
+	// empty, from History makes it linearizable.
+	History []HistoryOp
+}
+
+func (e *LinearizabilityError) Error() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "history is not linearizable (%v); minimal non-linearizable sub-history:", e.Order)
+	for _, op := range e.History {
+		s.WriteString("\n\t")
+		s.WriteString(op.String())
+	}
+	return s.String()
+}
+
+// CheckLinearizable checks whether the concurrent history is linearizable with respect to a sequential
+// FIFO queue or LIFO stack, i.e. whether every operation can be assigned a point in time, between its
+// call and return, in which it takes effect, such that the operations in that order are valid for a
+// queue or stack. The history items are expected to be added only once.
+// CheckLinearizable returns a *LinearizabilityError holding a minimal non-linearizable sub-history
+// if the history is not linearizable.
+//
+// Checking linearizability is NP-complete in general, so CheckLinearizable is meant to check short
+// histories, i.e. of a few hundred operations, run by a few goroutines.
+func CheckLinearizable(history []HistoryOp, order Order) error {
+	if order != FIFO && order != LIFO {
+		return fmt.Errorf("benchmark: linearizability can't be checked against order %v", order)
+	}
+	ops := make([]HistoryOp, len(history))
+	copy(ops, history)
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].Call < ops[j].Call })
+	if linearizable(ops, order) {
+		return nil
+	}
+	return &LinearizabilityError{Order: order, History: minimize(ops, order)}
+}
+
+// linearizable returns whether the history, sorted by call time, is linearizable.
+// linearizable implements the Wing & Gong search, with the memoization of the visited states
+// proposed by Lowe: at each step, any operation invoked before the earliest return of the
+// operations not linearized yet may take effect next.
+func linearizable(ops []HistoryOp, order Order) bool {
+	l := &linearizer{
+		ops:     ops,
+		lifo:    order == LIFO,
+		done:    make([]bool, len(ops)),
+		visited: make(map[string]struct{}),
+	}
+	return l.search(nil, 0)
+}
+
+// linearizer holds the state of the linearizability search.
+type linearizer struct {
+	ops  []HistoryOp
+	lifo bool
+
+	// done flags the linearized operations.
+	done []bool
+
+	// visited holds the visited states, each one encoded as the linearized operations and the items
+	// in the data structure.
+	visited map[string]struct{}
+
+	// key is a buffer used to encode the states.
+	key []byte
+}
+
+// search returns whether the operations not linearized yet can be linearized, starting with the
+// data structure holding items. items is never modified in place, as it's shared by the states.
+func (l *linearizer) search(items []int, linearized int) bool {
+	if linearized == len(l.ops) {
+		return true
+	}
+	if !l.visit(items) {
+		return false
+	}
+
+	minReturn := time.Duration(-1)
+	for i, op := range l.ops {
+		if !l.done[i] && (minReturn < 0 || op.Return < minReturn) {
+			minReturn = op.Return
+		}
+	}
+	for i, op := range l.ops {
+		if op.Call > minReturn {
+			// The operations are sorted by call time, so all the next operations returned
+			// after an operation not linearized yet was invoked.
+			break
+		}
+		if l.done[i] {
+			continue
+		}
+		next, ok := l.apply(items, op)
+		if !ok {
+			continue
+		}
+		l.done[i] = true
+		if l.search(next, linearized+1) {
+			return true
+		}
+		l.done[i] = false
+	}
+	return false
+}
+
+// visit marks the state as visited, returning false if it was already visited.
+func (l *linearizer) visit(items []int) bool {
+	l.key = l.key[:0]
+	var bits byte
+	for i, d := range l.done {
+		if d {
+			bits |= 1 << (i % 8)
+		}
+		if i%8 == 7 || i == len(l.done)-1 {
+			l.key = append(l.key, bits)
+			bits = 0
+		}
+	}
+	var buf [binary.MaxVarintLen64]byte
+	for _, item := range items {
+		n := binary.PutVarint(buf[:], int64(item))
+		l.key = append(l.key, buf[:n]...)
+	}
+	if _, ok := l.visited[string(l.key)]; ok {
+		return false
+	}
+	l.visited[string(l.key)] = struct{}{}
+	return true
+}
+
+// apply applies the operation to the data structure holding items, returning the items after the
+// operation and whether the operation result is valid.
+func (l *linearizer) apply(items []int, op HistoryOp) ([]int, bool) {
+	switch {
+	case op.Kind == OpAdd:
+		next := make([]int, len(items), len(items)+1)
+		copy(next, items)
+		return append(next, op.Item), true
+	case op.Kind == OpRemove && !op.Ok:
+		return items, len(items) == 0
+	case op.Kind == OpRemove && len(items) == 0:
+		return items, false
+	case op.Kind == OpRemove && l.lifo:
+		return items[:len(items)-1], items[len(items)-1] == op.Item
+	case op.Kind == OpRemove:
+		return items[1:], items[0] == op.Item
+	}
+	return items, false
+}
+
+// minimize returns a minimal non-linearizable sub-history of the non-linearizable history, sorted by
+// call time. minimize removes the operations in units, i.e. the add and removes of an item or a remove
+// that found the data structure empty, so the sub-history doesn't become non-linearizable just because
+// an item was removed without being added. The units are removed first in large chunks and then one
+// by one, until no unit can be removed.
+func minimize(ops []HistoryOp, order Order) []HistoryOp {
+	var units [][]int
+	items := make(map[int]int)
+	for i, op := range ops {
+		if op.Kind == OpRemove && !op.Ok {
+			units = append(units, []int{i})
+			continue
+		}
+		u, ok := items[op.Item]
+		if !ok {
+			u = len(units)
+			items[op.Item] = u
+			units = append(units, nil)
+		}
+		units[u] = append(units[u], i)
+	}
+
+	kept := make([]bool, len(units))
+	for u := range kept {
+		kept[u] = true
+	}
+	subHistory := func() []HistoryOp {
+		keep := make([]bool, len(ops))
+		for u, k := range kept {
+			if k {
+				for _, i := range units[u] {
+					keep[i] = true
+				}
+			}
+		}
+		var sub []HistoryOp
+		for i, op := range ops {
+			if keep[i] {
+				sub = append(sub, op)
+			}
+		}
+		return sub
+	}
+	// drop removes the units from, inclusive, to to, exclusive, if the history remains non-linearizable.
+	drop := func(from, to int) bool {
+		if to > len(units) {
+			to = len(units)
+		}
+		var dropped []int
+		for u := from; u < to; u++ {
+			if kept[u] {
+				kept[u] = false
+				dropped = append(dropped, u)
+			}
+		}
+		if len(dropped) == 0 {
+			return false
+		}
+		if !linearizable(subHistory(), order) {
+			return true
+		}
+		for _, u := range dropped {
+			kept[u] = true
+		}
+		return false
+	}
+
+	for chunk := len(units) / 2; chunk > 1; chunk /= 2 {
+		for from := 0; from < len(units); from += chunk {
+			drop(from, from+chunk)
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for u := range units {
+			if kept[u] && drop(u, u+1) {
+				changed = true
+			}
+		}
+	}
+	return subHistory()
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// historyAdd returns the add of the item run by the goroutine between call and ret.
+func historyAdd(goroutine, item int, call, ret time.Duration) HistoryOp {
+	return HistoryOp{Goroutine: goroutine, Kind: OpAdd, Item: item, Call: call, Return: ret}
+}
+
+// historyRemove returns the remove of the item run by the goroutine between call and ret.
+func historyRemove(goroutine, item int, call, ret time.Duration) HistoryOp {
+	return HistoryOp{Goroutine: goroutine, Kind: OpRemove, Item: item, Ok: true, Call: call, Return: ret}
+}
+
+// historyEmpty returns a remove that found the data structure empty, run by the goroutine between call and ret.
+func historyEmpty(goroutine int, call, ret time.Duration) HistoryOp {
+	return HistoryOp{Goroutine: goroutine, Kind: OpRemove, Call: call, Return: ret}
+}
+
+func TestCheckLinearizable(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []HistoryOp
+
+		// fifo and lifo set whether the history is linearizable for a queue and for a stack.
+		fifo, lifo bool
+	}{
+		{
+			name: "Empty",
+			fifo: true,
+			lifo: true,
+		},
+		{
+			name: "SequentialQueue",
+			history: []HistoryOp{
+				historyAdd(0, 1, 0, 1), historyAdd(0, 2, 2, 3), historyRemove(0, 1, 4, 5), historyRemove(0, 2, 6, 7),
+			},
+			fifo: true,
+		},
+		{
+			name: "SequentialStack",
+			history: []HistoryOp{
+				historyAdd(0, 1, 0, 1), historyAdd(0, 2, 2, 3), historyRemove(0, 2, 4, 5), historyRemove(0, 1, 6, 7),
+			},
+			lifo: true,
+		},
+		{
+			name: "ConcurrentAdds",
+			history: []HistoryOp{
+				historyAdd(0, 1, 0, 10), historyAdd(1, 2, 0, 10), historyRemove(0, 2, 20, 30), historyRemove(1, 1, 40, 50),
+			},
+			fifo: true,
+			lifo: true,
+		},
+		{
+			name: "ConcurrentRemoves",
+			history: []HistoryOp{
+				historyAdd(0, 1, 0, 1), historyAdd(0, 2, 2, 3), historyRemove(0, 2, 4, 10), historyRemove(1, 1, 4, 10),
+			},
+			fifo: true,
+			lifo: true,
+		},
+		{
+			name: "Unsorted",
+			history: []HistoryOp{
+				historyRemove(0, 2, 6, 7), historyAdd(0, 2, 2, 3), historyRemove(0, 1, 4, 5), historyAdd(0, 1, 0, 1),
+			},
+			fifo: true,
+		},
+		{
+			name:    "RemoveNeverAdded",
+			history: []HistoryOp{historyAdd(0, 1, 0, 1), historyRemove(1, 2, 2, 3)},
+		},
+		{
+			name:    "RemoveTwice",
+			history: []HistoryOp{historyAdd(0, 1, 0, 1), historyRemove(0, 1, 2, 3), historyRemove(1, 1, 4, 5)},
+		},
+		{
+			name:    "RemoveBeforeAdd",
+			history: []HistoryOp{historyRemove(0, 1, 0, 1), historyAdd(1, 1, 2, 3)},
+		},
+		{
+			name:    "RemoveDuringAdd",
+			history: []HistoryOp{historyRemove(0, 1, 0, 10), historyAdd(1, 1, 2, 3)},
+			fifo:    true,
+			lifo:    true,
+		},
+		{
+			name:    "EmptyWithItem",
+			history: []HistoryOp{historyAdd(0, 1, 0, 1), historyEmpty(1, 2, 3)},
+		},
+		{
+			name:    "EmptyDuringAdd",
+			history: []HistoryOp{historyAdd(0, 1, 0, 10), historyEmpty(1, 2, 3), historyRemove(1, 1, 20, 30)},
+			fifo:    true,
+			lifo:    true,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		for _, o := range []struct {
+			order Order
+			want  bool
+		}{{FIFO, test.fifo}, {LIFO, test.lifo}} {
+			t.Run(test.name+"/"+o.order.String(), func(t *testing.T) {
+				err := CheckLinearizable(test.history, o.order)
+				var lerr *LinearizabilityError
+				switch {
+				case o.want && err != nil:
+					t.Fatalf("got error %v, want nil", err)
+				case !o.want && !errors.As(err, &lerr):
+					t.Fatalf("got error %v, want a *LinearizabilityError", err)
+				case !o.want && lerr.Order != o.order:
+					t.Fatalf("got order %v, want %v", lerr.Order, o.order)
+				case !o.want && !strings.HasPrefix(err.Error(), "history is not linearizable ("+o.order.String()+")"):
+					t.Fatalf("got error %q, want it to start with the order", err)
+				}
+			})
+		}
+	}
+}
+
+func TestCheckLinearizableOrder(t *testing.T) {
+	var lerr *LinearizabilityError
+	if err := CheckLinearizable(nil, MinPriority); err == nil || errors.As(err, &lerr) {
+		t.Fatalf("got error %v, want an invalid order error", err)
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   Order
+		history []HistoryOp
+		want    []HistoryOp
+	}{
+		{
+			name:  "WrongOrder",
+			order: FIFO,
+			history: []HistoryOp{
+				historyAdd(0, 1, 0, 1), historyAdd(0, 2, 2, 3), historyRemove(0, 2, 4, 5), historyRemove(0, 1, 6, 7),
+				historyAdd(1, 3, 8, 9), historyRemove(1, 3, 10, 11), historyEmpty(1, 12, 13),
+			},
+			want: []HistoryOp{
+				historyAdd(0, 1, 0, 1), historyAdd(0, 2, 2, 3), historyRemove(0, 2, 4, 5), historyRemove(0, 1, 6, 7),
+			},
+		},
+		{
+			name:  "EmptyWithItem",
+			order: LIFO,
+			history: []HistoryOp{
+				historyAdd(0, 1, 0, 1), historyEmpty(1, 2, 3), historyAdd(0, 2, 4, 5), historyRemove(0, 2, 6, 7),
+				historyRemove(0, 1, 8, 9),
+			},
+			want: []HistoryOp{historyAdd(0, 1, 0, 1), historyEmpty(1, 2, 3), historyRemove(0, 1, 8, 9)},
+		},
+		{
+			name:    "AlreadyMinimal",
+			order:   FIFO,
+			history: []HistoryOp{historyAdd(0, 1, 0, 1), historyRemove(0, 1, 2, 3), historyRemove(1, 1, 4, 5)},
+			want:    []HistoryOp{historyAdd(0, 1, 0, 1), historyRemove(0, 1, 2, 3), historyRemove(1, 1, 4, 5)},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if got := minimize(test.history, test.order); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+			var lerr *LinearizabilityError
+			if err := CheckLinearizable(test.history, test.order); !errors.As(err, &lerr) || !reflect.DeepEqual(lerr.History, test.want) {
+				t.Fatalf("got error %v, want the minimal history %v", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"runtime"
+	"testing"
+)
+
+// memoryCalibrationValues is the number of values built to measure the heap held by each value.
+const memoryCalibrationValues = 1024
+
+// ShrinkLimits is the heap a data structure may retain after being drained.
+// The data structure fails the shrink check if it retains more than both limits.
+type ShrinkLimits struct {
+	// Ratio is the fraction of the heap held by the data structure at its peak that it may retain
+	// after being drained, i.e. 0.25 allows the data structure to retain a quarter of its peak heap.
+	Ratio float64
+
+	// Bytes is the heap the data structure may retain after being drained regardless of its peak
+	// heap, i.e. the size of the internal slice the data structure keeps when empty.
+	Bytes int64
+}
+
+// allows returns whether the data structure may retain the heap after being drained, given its peak heap.
+func (l *ShrinkLimits) allows(retained, peak int64) bool {
+	return retained <= l.Bytes || float64(retained) <= l.Ratio*float64(peak)
+}
+
+// memoryMetrics measures the heap held by the data structures being tested.
+type memoryMetrics struct {
+	// valueBytes is the heap held by each value built by the harness, i.e. a *TestValue object.
+	valueBytes float64
+	calibrated bool
+
+	// baseline is the live heap right after the data structure was initialized.
+	baseline int64
+
+	// stale is whether the baseline must be measured again the next time the data structure is initialized.
+	stale bool
+
+	// peakLen is the largest number of items the data structure held when its heap was measured in the run
+	// and peak is the heap it held then.
+	peakLen int
+	peak    int64
+
+	// retained is the heap the data structure held after being drained, if drained is set.
+	retained int64
+	drained  bool
+
+	// limits is the heap the data structure may retain after being drained; nil if not checked.
+	limits *ShrinkLimits
+
+	// draining is whether the heap must be measured once the data structure is drained, i.e. the peak was
+	// measured and the data structure was not drained or initialized since.
+	draining bool
+
+	ms runtime.MemStats
+}
+
+func newMemoryMetrics(limits *ShrinkLimits) *memoryMetrics {
+	return &memoryMetrics{stale: true, limits: limits}
+}
+
+// heap returns the live heap, after running a garbage collection.
+func (m *memoryMetrics) heap() int64 {
+	runtime.GC()
+	runtime.ReadMemStats(&m.ms)
+	return int64(m.ms.HeapAlloc)
+}
+
+// due returns whether the heap must be measured when the data structure holds len items, i.e. it holds more
+// items than in any previous measure of the run, or it was drained after the last such measure.
+func (m *memoryMetrics) due(len int) bool {
+	return len > m.peakLen || len == 0 && m.draining
+}
+
+// held returns the heap held by the data structure, given the heap held by the values it holds.
+func (m *memoryMetrics) held(values int64) int64 {
+	held := m.heap() - m.baseline - values
+	if held < 0 {
+		return 0
+	}
+	return held
+}
+
+// reset removes the measures of the run. The baseline is measured again the next time the data structure
+// is initialized, but is kept for the tests that run with an instance initialized before the run.
+func (m *memoryMetrics) reset() {
+	m.stale = true
+	m.peakLen, m.peak = 0, 0
+	m.retained, m.drained, m.draining = 0, false, false
+}
+
+// report reports the heap held by the data structure at the peak, per item and in total, and after being drained,
+// in total and as a fraction of the peak heap. report fails the benchmark if the data structure retained more heap
+// than its shrink limits allow.
+func (m *memoryMetrics) report(b *testing.B) {
+	if m.peakLen > 0 {
+		b.ReportMetric(float64(m.peak)/float64(m.peakLen), "peak-B/item")
+		b.ReportMetric(float64(m.peak), "peak-B")
+	}
+	if !m.drained {
+		return
+	}
+	b.ReportMetric(float64(m.retained), "retained-B")
+	if m.peak > 0 {
+		b.ReportMetric(float64(m.retained)/float64(m.peak), "retained/peak")
+	}
+	if m.limits != nil && !m.limits.allows(m.retained, m.peak) {
+		b.Errorf("data structure retained %d bytes after being drained from a peak of %d bytes with %d items, want at most %d bytes or %.0f%% of the peak",
+			m.retained, m.peak, m.peakLen, m.limits.Bytes, m.limits.Ratio*100)
+	}
+}
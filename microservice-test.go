@@ -22,120 +22,82 @@
 // and efficiency of data structures.
 package benchmark
 
-import (
-	"strconv"
-	"testing"
-)
+import "testing"
 
 // Microservice tests the data structures performance by simulating the data structure being used by microservice
 // and serverless systems when running in production environments.
 func (t *Tests) Microservice(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
-	for _, test := range tests {
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
-			for n := 0; n < b.N; n++ {
-				initInstance()
-
-				// Simulate stable traffic
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					remove()
-				}
-
-				// Simulate slowly increasing traffic
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					add(GetTestValue(i))
-					remove()
-				}
-
-				// Simulate slowly decreasing traffic, bringing traffic back to normal
-				for i := 0; i < test.count; i++ {
-					remove()
-					if !empty() {
-						remove()
-					}
-					add(GetTestValue(i))
-				}
-
-				// Simulate quick traffic spike (DDOS attack, etc)
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-				}
-
-				// Simulate stable traffic while at high traffic
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					remove()
-				}
-
-				// Simulate going back to normal (DDOS attack fended off)
-				for i := 0; i < test.count; i++ {
-					remove()
-				}
-
-				// Simulate stable traffic (now that is back to normal)
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					remove()
-				}
-			}
-		})
-	}
+	t.untyped().Microservice(b, initInstance, add, remove, empty)
 }
 
 // MicroserviceTestObject tests the data structures performance by simulating the data structure being used by microservice
 // and serverless systems when running in production environments.
-// MicroserviceTestObject is a copy of Microservice that operates on *TestValue object which allows data structures that suport
+// MicroserviceTestObject is a version of Microservice that operates on *TestValue object which allows data structures that suport
 // generics to not need to perform any type cast in the benchmark tests.
 func (t *Tests) MicroserviceTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
-	for _, test := range tests {
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
+	t.testObject().Microservice(b, initInstance, add, remove, empty)
+}
+
+// Microservice tests the data structures performance by simulating the data structure being used by microservice
+// and serverless systems when running in production environments.
+func (t *TypedTests[T]) Microservice(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	for _, count := range t.sizes("Microservice") {
+		h.run(count, func(b *testing.B) {
 			for n := 0; n < b.N; n++ {
-				initInstance()
+				h.init()
 
 				// Simulate stable traffic
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					remove()
+				h.phase("stable")
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.remove()
 				}
 
 				// Simulate slowly increasing traffic
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					add(GetTestValue(i))
-					remove()
+				h.phase("slow-increase")
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.add(i)
+					h.remove()
 				}
 
 				// Simulate slowly decreasing traffic, bringing traffic back to normal
-				for i := 0; i < test.count; i++ {
-					remove()
-					if !empty() {
-						remove()
+				h.phase("slow-decrease")
+				for i := 0; i < count; i++ {
+					h.remove()
+					if !h.empty() {
+						h.remove()
 					}
-					add(GetTestValue(i))
+					h.add(i)
 				}
 
 				// Simulate quick traffic spike (DDOS attack, etc)
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
+				h.phase("spike")
+				for i := 0; i < count; i++ {
+					h.add(i)
 				}
 
 				// Simulate stable traffic while at high traffic
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					remove()
+				h.phase("high-stable")
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.remove()
 				}
 
 				// Simulate going back to normal (DDOS attack fended off)
-				for i := 0; i < test.count; i++ {
-					remove()
+				h.phase("recovery")
+				for i := 0; i < count; i++ {
+					h.remove()
 				}
 
 				// Simulate stable traffic (now that is back to normal)
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					remove()
+				h.phase("normal")
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.remove()
 				}
+				h.phase("")
 			}
 		})
 	}
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"strconv"
+	"testing"
+)
+
+// runNull runs f against a null data structure, which does no work, as a sub-benchmark named "null/" followed by
+// the number of items in the test, so its results measure the overhead of the harness and of the test itself.
+// The harness state is restored once done, so the data structure is tested as if runNull never ran.
+func (h *harness[T]) runNull(count int, f func(b *testing.B)) {
+	saved := *h
+	defer func() {
+		pool := h.pool
+		*h = saved
+		h.pool = pool
+	}()
+
+	h.initInstance = func() {}
+	h.addFn = h.nullAdd
+	h.removeFn = h.nullRemove
+	h.emptyFn = h.nullEmpty
+	if h.pushFrontFn != nil {
+		h.pushFrontFn = h.nullAdd
+	}
+	if h.popBackFn != nil {
+		h.popBackFn = h.nullRemove
+	}
+	if h.peekFn != nil {
+		h.peekFn = h.nullRemove
+	}
+	if h.lenFn != nil {
+		h.lenFn = h.nullLen
+	}
+	if h.pushFn != nil {
+		h.pushFn = func(int, T) {}
+	}
+	if h.updateFn != nil {
+		h.updateFn = func(T, int) {}
+	}
+	h.model, h.phases, h.latencies, h.memory = nil, nil, nil, nil
+	saved.b.Run("null/"+strconv.Itoa(count), func(b *testing.B) {
+		h.b = b
+		h.reserve(count)
+		b.ResetTimer()
+		f(b)
+	})
+}
+
+// nullAdd is the add function of the null data structure.
+func (h *harness[T]) nullAdd(v T) {}
+
+// nullRemove is the remove function of the null data structure, which holds as many items as the harness expects.
+func (h *harness[T]) nullRemove() (T, bool) {
+	var zero T
+	return zero, h.len > 0
+}
+
+// nullEmpty is the empty function of the null data structure.
+func (h *harness[T]) nullEmpty() bool {
+	return h.len == 0
+}
+
+// nullLen is the len function of the null data structure.
+func (h *harness[T]) nullLen() int {
+	return h.len
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+)
+
+func TestOverhead(t *testing.T) {
+	config := testConfig()
+	config.Overhead = true
+	tests := &TypedTests[*TestValue]{Config: config}
+	c := newCounting[*TestValue]()
+	// testing.Benchmark doesn't name the sub-benchmarks, so the runs are told apart by their testing.B.
+	var runs []*testing.B
+	var instances []int
+	runBenchmark(t, func(b *testing.B) {
+		h := tests.harness(b, c.Init, c.Add, c.Remove, c.Empty)
+		h.run(3, func(b *testing.B) {
+			runs = append(runs, b)
+			for n := 0; n < b.N; n++ {
+				h.init()
+				for i := 0; i < 3; i++ {
+					h.add(i)
+				}
+				for !h.empty() {
+					h.remove()
+				}
+			}
+			instances = append(instances, len(c.instances))
+		})
+	})
+
+	if len(runs) != 2 || runs[0] == runs[1] {
+		t.Fatalf("ran f in %d sub-benchmarks, want 2", len(runs))
+	}
+	// The null run, which runs first, doesn't touch the data structure.
+	if want := []int{0, 1}; len(instances) != 2 || instances[0] != want[0] || instances[1] != want[1] {
+		t.Fatalf("got %v instances initialized after each sub-benchmark, want %v", instances, want)
+	}
+	if got, want := *c.instances[0], (instance{adds: 3, removes: 3, empties: 4, max: 3, order: c.instances[0].order}); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+// BenchmarkOverheadNames runs the Fill test with the overhead measured, for TestOverheadNames.
+func BenchmarkOverheadNames(b *testing.B) {
+	if os.Getenv("BENCHMARK_OVERHEAD_NAMES") == "" {
+		b.Skip("run by TestOverheadNames")
+	}
+	tests := &Tests{Config: Config{Sizes: []int{3}, Overhead: true}}
+	c := newCounting[interface{}]()
+	tests.Fill(b, c.Init, c.Add, c.Remove, c.Empty)
+}
+
+func TestOverheadNames(t *testing.T) {
+	// testing.Benchmark doesn't name the sub-benchmarks, so the benchmark runs in a new test process.
+	cmd := exec.Command(os.Args[0], "-test.run=^$", "-test.bench=^BenchmarkOverheadNames$", "-test.benchtime=1x", "-test.cpu=1")
+	cmd.Env = append(os.Environ(), "BENCHMARK_OVERHEAD_NAMES=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	names := regexp.MustCompile(`(?m)^(BenchmarkOverheadNames\S*)\s`).FindAllStringSubmatch(string(out), -1)
+	if len(names) != 2 || names[0][1] != "BenchmarkOverheadNames/null/3" || names[1][1] != "BenchmarkOverheadNames/3" {
+		t.Fatalf("ran the sub-benchmarks %q, want null/3 and then 3\n%s", names, out)
+	}
+}
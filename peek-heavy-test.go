@@ -0,0 +1,76 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import "testing"
+
+// PeekHeavy tests the data structures performance by sequentially adding n items to the data structure and then,
+// until the data structure is empty, peeking the next item 10 times (Config.PeekRatio) and removing it.
+// PeekHeavy simulates consumers, such as schedulers, that check the next item far more often than they remove it.
+// PeekHeavy checks whether the data structure has items with Config.Len, if set, or with empty otherwise.
+// PeekHeavy tests the data structures ability to quickly return the next item.
+func (t *Tests) PeekHeavy(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool, peek func() (interface{}, bool)) {
+	t.untyped().PeekHeavy(b, initInstance, add, remove, empty, peek)
+}
+
+// PeekHeavyTestObject tests the data structures performance by sequentially adding n items to the data structure and then,
+// until the data structure is empty, peeking the next item 10 times (Config.PeekRatio) and removing it.
+// PeekHeavyTestObject is a version of PeekHeavy that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) PeekHeavyTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool, peek func() (*TestValue, bool)) {
+	t.testObject().PeekHeavy(b, initInstance, add, remove, empty, peek)
+}
+
+// PeekHeavy tests the data structures performance by sequentially adding n items to the data structure and then,
+// until the data structure is empty, peeking the next item 10 times (Config.PeekRatio) and removing it.
+// PeekHeavy simulates consumers, such as schedulers, that check the next item far more often than they remove it.
+// PeekHeavy checks whether the data structure has items with Config.Len, if set, or with empty otherwise.
+// PeekHeavy tests the data structures ability to quickly return the next item.
+func (t *TypedTests[T]) PeekHeavy(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool, peek func() (T, bool)) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	h.peekFn = peek
+	ratio := t.peekRatio()
+	hasItems := func() bool { return !h.empty() }
+	if h.lenFn != nil {
+		hasItems = func() bool { return h.length() > 0 }
+	}
+	for _, count := range t.sizes("PeekHeavy") {
+		h.run(count, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				h.init()
+				for i := 0; i < count; i++ {
+					h.add(i)
+				}
+				h.footprint()
+				for hasItems() {
+					for k := 0; k < ratio; k++ {
+						h.peek()
+					}
+					h.remove()
+				}
+				h.footprint()
+			}
+			b.ReportMetric(float64(ratio), "peek-ratio")
+		})
+	}
+}
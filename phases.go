@@ -0,0 +1,100 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// phaseMetrics measures the time, allocations and allocated bytes of each phase of a test.
+type phaseMetrics struct {
+	// phases holds the metrics of each phase, in the order the phases run.
+	phases []phase
+
+	// index maps the phase names to their position in phases.
+	index map[string]int
+
+	// current is the position of the running phase in phases; -1 if no phase is running.
+	current int
+
+	// start is the time the running phase started.
+	start  time.Time
+	allocs uint64
+	bytes  uint64
+	ms     runtime.MemStats
+}
+
+// phase holds the accumulated metrics of a test phase.
+type phase struct {
+	name   string
+	ns     time.Duration
+	allocs uint64
+	bytes  uint64
+}
+
+func newPhaseMetrics() *phaseMetrics {
+	return &phaseMetrics{
+		index:   make(map[string]int),
+		current: -1,
+	}
+}
+
+// next ends the running phase, if any, at the given time and starts the named phase.
+// An empty name ends the running phase without starting a new one.
+// The caller must set start once the new phase starts running.
+func (m *phaseMetrics) next(name string, end time.Time) {
+	runtime.ReadMemStats(&m.ms)
+	if m.current >= 0 {
+		p := &m.phases[m.current]
+		p.ns += end.Sub(m.start)
+		p.allocs += m.ms.Mallocs - m.allocs
+		p.bytes += m.ms.TotalAlloc - m.bytes
+	}
+
+	m.current = -1
+	if name == "" {
+		return
+	}
+	i, ok := m.index[name]
+	if !ok {
+		i = len(m.phases)
+		m.index[name] = i
+		m.phases = append(m.phases, phase{name: name})
+	}
+	m.current = i
+	m.allocs, m.bytes = m.ms.Mallocs, m.ms.TotalAlloc
+}
+
+// report reports the metrics of each phase, per benchmark iteration, and resets them.
+func (m *phaseMetrics) report(b *testing.B) {
+	for _, p := range m.phases {
+		b.ReportMetric(float64(p.ns.Nanoseconds())/float64(b.N), p.name+"-ns/op")
+		b.ReportMetric(float64(p.allocs)/float64(b.N), p.name+"-allocs/op")
+		b.ReportMetric(float64(p.bytes)/float64(b.N), p.name+"-B/op")
+	}
+	m.phases = m.phases[:0]
+	m.index = make(map[string]int)
+	m.current = -1
+}
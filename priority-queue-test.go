@@ -0,0 +1,291 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import "testing"
+
+// PriorityFill tests the priority queues performance by sequentially pushing n items and then removing all pushed items.
+// PriorityFill tests the priority queues ability for quickly expand and shrink.
+// PriorityFill runs for each priorities distribution set in Config.Priorities.
+func (t *Tests) PriorityFill(b *testing.B, initInstance func(), push func(priority int, v interface{}), popMin func() (interface{}, bool), empty func() bool) {
+	t.untyped().PriorityFill(b, initInstance, push, popMin, empty)
+}
+
+// PriorityFillTestObject tests the priority queues performance by sequentially pushing n items and then removing all pushed items.
+// PriorityFillTestObject tests the priority queues ability for quickly expand and shrink.
+// PriorityFillTestObject is a version of PriorityFill that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) PriorityFillTestObject(b *testing.B, initInstance func(), push func(priority int, v *TestValue), popMin func() (*TestValue, bool), empty func() bool) {
+	t.testObject().PriorityFill(b, initInstance, push, popMin, empty)
+}
+
+// PriorityFill tests the priority queues performance by sequentially pushing n items and then removing all pushed items.
+// PriorityFill tests the priority queues ability for quickly expand and shrink.
+// PriorityFill runs for each priorities distribution set in Config.Priorities.
+func (t *TypedTests[T]) PriorityFill(b *testing.B, initInstance func(), push func(priority int, v T), popMin func() (T, bool), empty func() bool) {
+	t.priorityRun(b, "PriorityFill", initInstance, push, popMin, empty, nil, func(h *harness[T], b *testing.B, count int) {
+		for n := 0; n < b.N; n++ {
+			h.init()
+			for i := 0; i < count; i++ {
+				h.push()
+			}
+			h.footprint()
+			for !h.empty() {
+				h.remove()
+			}
+			h.footprint()
+		}
+	})
+}
+
+// PriorityRefill tests the priority queues performance by sequentially pushing n items and then removing all pushed items,
+// repeating the test 100 times using the same priority queue instance.
+// PriorityRefill tests the priority queues ability to fill again once it has been filled and emptied.
+// PriorityRefill runs for each priorities distribution set in Config.Priorities.
+func (t *Tests) PriorityRefill(b *testing.B, initInstance func(), push func(priority int, v interface{}), popMin func() (interface{}, bool), empty func() bool) {
+	t.untyped().PriorityRefill(b, initInstance, push, popMin, empty)
+}
+
+// PriorityRefillTestObject tests the priority queues performance by sequentially pushing n items and then removing all pushed items,
+// repeating the test 100 times using the same priority queue instance.
+// PriorityRefillTestObject is a version of PriorityRefill that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) PriorityRefillTestObject(b *testing.B, initInstance func(), push func(priority int, v *TestValue), popMin func() (*TestValue, bool), empty func() bool) {
+	t.testObject().PriorityRefill(b, initInstance, push, popMin, empty)
+}
+
+// PriorityRefill tests the priority queues performance by sequentially pushing n items and then removing all pushed items,
+// repeating the test 100 times using the same priority queue instance.
+// PriorityRefill tests the priority queues ability to fill again once it has been filled and emptied.
+// PriorityRefill runs for each priorities distribution set in Config.Priorities.
+func (t *TypedTests[T]) PriorityRefill(b *testing.B, initInstance func(), push func(priority int, v T), popMin func() (T, bool), empty func() bool) {
+	refillCount := t.refillCount()
+	t.priorityRun(b, "PriorityRefill", initInstance, push, popMin, empty, nil, func(h *harness[T], b *testing.B, count int) {
+		h.prepare(0)
+		for n := 0; n < b.N; n++ {
+			for k := 0; k < refillCount; k++ {
+				for i := 0; i < count; i++ {
+					h.push()
+				}
+				h.footprint()
+				for !h.empty() {
+					h.remove()
+				}
+				h.footprint()
+			}
+		}
+		b.ReportMetric(float64(refillCount), "refills")
+	})
+}
+
+// PriorityStable tests the priority queues performance by pushing 1 item and removing the item with the lowest priority,
+// n times, after filling the priority queue with Config.FillCount items.
+// PriorityStable tests the priority queues ability to handle constant push/pop over n iterations.
+// PriorityStable runs for each priorities distribution set in Config.Priorities.
+func (t *Tests) PriorityStable(b *testing.B, initInstance func(), push func(priority int, v interface{}), popMin func() (interface{}, bool), empty func() bool) {
+	t.untyped().PriorityStable(b, initInstance, push, popMin, empty)
+}
+
+// PriorityStableTestObject tests the priority queues performance by pushing 1 item and removing the item with the lowest priority,
+// n times, after filling the priority queue with Config.FillCount items.
+// PriorityStableTestObject is a version of PriorityStable that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) PriorityStableTestObject(b *testing.B, initInstance func(), push func(priority int, v *TestValue), popMin func() (*TestValue, bool), empty func() bool) {
+	t.testObject().PriorityStable(b, initInstance, push, popMin, empty)
+}
+
+// PriorityStable tests the priority queues performance by pushing 1 item and removing the item with the lowest priority,
+// n times, after filling the priority queue with Config.FillCount items.
+// PriorityStable tests the priority queues ability to handle constant push/pop over n iterations.
+// PriorityStable runs for each priorities distribution set in Config.Priorities.
+func (t *TypedTests[T]) PriorityStable(b *testing.B, initInstance func(), push func(priority int, v T), popMin func() (T, bool), empty func() bool) {
+	fillCount := t.fillCount()
+	t.priorityRun(b, "PriorityStable", initInstance, push, popMin, empty, nil, func(h *harness[T], b *testing.B, count int) {
+		h.preparePriorityQueue(fillCount)
+		for n := 0; n < b.N; n++ {
+			h.footprint()
+			for i := 0; i < count; i++ {
+				h.push()
+				h.remove()
+			}
+		}
+		b.ReportMetric(float64(fillCount), "fill-items")
+	})
+}
+
+// PriorityMicroservice tests the priority queues performance by simulating the priority queue being used by microservice
+// and serverless systems when running in production environments.
+// PriorityMicroservice runs for each priorities distribution set in Config.Priorities.
+func (t *Tests) PriorityMicroservice(b *testing.B, initInstance func(), push func(priority int, v interface{}), popMin func() (interface{}, bool), empty func() bool) {
+	t.untyped().PriorityMicroservice(b, initInstance, push, popMin, empty)
+}
+
+// PriorityMicroserviceTestObject tests the priority queues performance by simulating the priority queue being used by microservice
+// and serverless systems when running in production environments.
+// PriorityMicroserviceTestObject is a version of PriorityMicroservice that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) PriorityMicroserviceTestObject(b *testing.B, initInstance func(), push func(priority int, v *TestValue), popMin func() (*TestValue, bool), empty func() bool) {
+	t.testObject().PriorityMicroservice(b, initInstance, push, popMin, empty)
+}
+
+// PriorityMicroservice tests the priority queues performance by simulating the priority queue being used by microservice
+// and serverless systems when running in production environments.
+// PriorityMicroservice runs for each priorities distribution set in Config.Priorities.
+func (t *TypedTests[T]) PriorityMicroservice(b *testing.B, initInstance func(), push func(priority int, v T), popMin func() (T, bool), empty func() bool) {
+	t.priorityRun(b, "PriorityMicroservice", initInstance, push, popMin, empty, nil, func(h *harness[T], b *testing.B, count int) {
+		for n := 0; n < b.N; n++ {
+			h.init()
+
+			// Simulate stable traffic
+			h.phase("stable")
+			for i := 0; i < count; i++ {
+				h.push()
+				h.remove()
+			}
+
+			// Simulate slowly increasing traffic
+			h.phase("slow-increase")
+			for i := 0; i < count; i++ {
+				h.push()
+				h.push()
+				h.remove()
+			}
+
+			// Simulate slowly decreasing traffic, bringing traffic back to normal
+			h.phase("slow-decrease")
+			for i := 0; i < count; i++ {
+				h.remove()
+				if !h.empty() {
+					h.remove()
+				}
+				h.push()
+			}
+
+			// Simulate quick traffic spike (DDOS attack, etc)
+			h.phase("spike")
+			for i := 0; i < count; i++ {
+				h.push()
+			}
+
+			// Simulate stable traffic while at high traffic
+			h.phase("high-stable")
+			for i := 0; i < count; i++ {
+				h.push()
+				h.remove()
+			}
+
+			// Simulate going back to normal (DDOS attack fended off)
+			h.phase("recovery")
+			for i := 0; i < count; i++ {
+				h.remove()
+			}
+
+			// Simulate stable traffic (now that is back to normal)
+			h.phase("normal")
+			for i := 0; i < count; i++ {
+				h.push()
+				h.remove()
+			}
+			h.phase("")
+		}
+	})
+}
+
+// PriorityUpdate tests the priority queues performance by sequentially pushing n items, then decreasing the priority of
+// every pushed item by a random amount between 1 and n, and then removing all pushed items.
+// PriorityUpdate tests the priority queues ability to efficiently change the priority of the items (decrease key).
+// PriorityUpdate runs for each priorities distribution set in Config.Priorities.
+func (t *Tests) PriorityUpdate(b *testing.B, initInstance func(), push func(priority int, v interface{}), popMin func() (interface{}, bool), empty func() bool, update func(v interface{}, priority int)) {
+	t.untyped().PriorityUpdate(b, initInstance, push, popMin, empty, update)
+}
+
+// PriorityUpdateTestObject tests the priority queues performance by sequentially pushing n items, then decreasing the priority of
+// every pushed item by a random amount between 1 and n, and then removing all pushed items.
+// PriorityUpdateTestObject is a version of PriorityUpdate that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) PriorityUpdateTestObject(b *testing.B, initInstance func(), push func(priority int, v *TestValue), popMin func() (*TestValue, bool), empty func() bool, update func(v *TestValue, priority int)) {
+	t.testObject().PriorityUpdate(b, initInstance, push, popMin, empty, update)
+}
+
+// PriorityUpdate tests the priority queues performance by sequentially pushing n items, then decreasing the priority of
+// every pushed item by a random amount between 1 and n, and then removing all pushed items.
+// PriorityUpdate tests the priority queues ability to efficiently change the priority of the items (decrease key).
+// PriorityUpdate runs for each priorities distribution set in Config.Priorities.
+func (t *TypedTests[T]) PriorityUpdate(b *testing.B, initInstance func(), push func(priority int, v T), popMin func() (T, bool), empty func() bool, update func(v T, priority int)) {
+	t.priorityRun(b, "PriorityUpdate", initInstance, push, popMin, empty, update, func(h *harness[T], b *testing.B, count int) {
+		h.pushed = make([]T, 0, count)
+		base := h.priority
+		updated := func(i int) int {
+			return base(i) - 1 - int(mix64(uint64(i))%uint64(count))
+		}
+		for n := 0; n < b.N; n++ {
+			h.priority = base
+			h.init()
+			for i := 0; i < count; i++ {
+				h.push()
+			}
+			for i := 0; i < count; i++ {
+				h.update(i, updated(i))
+			}
+			h.reprioritize(updated)
+			h.footprint()
+			for !h.empty() {
+				h.remove()
+			}
+			h.footprint()
+		}
+		h.priority = base
+	})
+}
+
+// priorityRun runs f, for each priorities distribution and size of the suite, as sub-benchmarks named after the
+// distribution and the size. update is the priority queue update function; nil if the suite doesn't update priorities.
+func (t *TypedTests[T]) priorityRun(b *testing.B, suite string, initInstance func(), push func(priority int, v T), popMin func() (T, bool), empty func() bool, update func(v T, priority int), f func(h *harness[T], b *testing.B, count int)) {
+	ps := t.Priorities
+	if ps == nil {
+		ps = priorities
+	}
+	seed := t.Mix.seed()
+	for _, p := range ps {
+		b.Run(p.String(), func(b *testing.B) {
+			h := t.harness(b, initInstance, nil, popMin, empty)
+			h.pushFn, h.updateFn = push, update
+			h.priority = p.generator(seed)
+			if h.model != nil {
+				// Priority queues are validated by the items priorities, regardless of the configured order.
+				count := t.count()
+				h.model = newOrderModel(MinPriority)
+				h.key = func(v T) int { return h.priority(count(v)) }
+				h.keyName = "priority"
+			}
+			for _, count := range t.sizes(suite) {
+				h.run(count, func(b *testing.B) {
+					f(h, b, count)
+					if p.random() {
+						reportSeed(b, seed)
+					}
+				})
+			}
+		})
+	}
+}
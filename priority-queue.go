@@ -0,0 +1,311 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"container/heap"
+	"fmt"
+	"reflect"
+)
+
+// Priorities is the distribution of the priorities of the items pushed to the priority queues.
+type Priorities int
+
+const (
+	// UniformPriorities draws the priorities at random from a uniform distribution.
+	UniformPriorities Priorities = iota
+
+	// AscendingPriorities pushes the items in ascending priority order, so the priority queues
+	// return the items in the order they were pushed.
+	AscendingPriorities
+
+	// DescendingPriorities pushes the items in descending priority order, so the priority queues
+	// return the items in the reverse order they were pushed.
+	DescendingPriorities
+
+	// DuplicatePriorities draws the priorities at random from a small set of 16 priorities, so
+	// many items share the same priority.
+	DuplicatePriorities
+)
+
+// priorities contains the priority distributions the priority queue tests run with by default.
+var priorities = []Priorities{UniformPriorities, AscendingPriorities, DescendingPriorities, DuplicatePriorities}
+
+// String returns the name of the distribution.
+func (p Priorities) String() string {
+	switch p {
+	case UniformPriorities:
+		return "uniform"
+	case AscendingPriorities:
+		return "ascending"
+	case DescendingPriorities:
+		return "descending"
+	case DuplicatePriorities:
+		return "duplicates"
+	}
+	return fmt.Sprintf("Priorities(%d)", int(p))
+}
+
+// random returns whether the priorities are drawn at random from a seed.
+func (p Priorities) random() bool {
+	return p == UniformPriorities || p == DuplicatePriorities
+}
+
+// generator returns a function that returns the priority of the i-th item pushed to the priority queue.
+// The priorities are a function of i only, so the priorities are the same in every test iteration.
+func (p Priorities) generator(seed int64) func(i int) int {
+	switch p {
+	case UniformPriorities:
+		return func(i int) int {
+			return int(mix64(uint64(seed)+uint64(i+1)*splitMixGamma) >> 33)
+		}
+	case AscendingPriorities:
+		return func(i int) int {
+			return i
+		}
+	case DescendingPriorities:
+		return func(i int) int {
+			return -i
+		}
+	case DuplicatePriorities:
+		return func(i int) int {
+			return int(mix64(uint64(seed)+uint64(i+1)*splitMixGamma) % 16)
+		}
+	}
+	panic("benchmark: invalid priorities " + p.String())
+}
+
+// HeapPriorityQueue is a min priority queue implemented over container/heap.
+// HeapPriorityQueue is a reference implementation to be used as the baseline in the priority queue tests.
+//
+//	var q benchmark.HeapPriorityQueue[*benchmark.TestValue]
+//	tests.PriorityFillTestObject(b, q.Init, q.Push, q.PopMin, q.Empty)
+type HeapPriorityQueue[T any] struct {
+	h priorityHeap[T]
+
+	// seq is the number of items pushed to the priority queue.
+	seq uint64
+}
+
+// Init initializes or clears the priority queue.
+func (q *HeapPriorityQueue[T]) Init() {
+	q.h = priorityHeap[T]{}
+}
+
+// Push adds the value to the priority queue with the priority.
+func (q *HeapPriorityQueue[T]) Push(priority int, v T) {
+	q.seq++
+	q.h.push(priorityItem[T]{priority: priority, value: v, seq: q.seq})
+	heap.Fix(&q.h, len(q.h.items)-1)
+}
+
+// PopMin removes and returns the value with the lowest priority.
+// The ok result is false if the priority queue is empty.
+// Push and PopMin don't use heap.Push and heap.Pop, which would allocate to box each item.
+func (q *HeapPriorityQueue[T]) PopMin() (T, bool) {
+	if len(q.h.items) == 0 {
+		var v T
+		return v, false
+	}
+	last := len(q.h.items) - 1
+	q.h.Swap(0, last)
+	item := q.h.pop()
+	if last > 0 {
+		heap.Fix(&q.h, 0)
+	}
+	return item.value, true
+}
+
+// Update changes the priority of the value. If the value was pushed more than once, Update changes the
+// priority of the one pushed first that is still in the priority queue. The values are looked up in
+// constant time with == or, if they can't be used as map keys, i.e. slices, with reflect.DeepEqual in
+// linear time.
+// Update does nothing if the value is not in the priority queue.
+func (q *HeapPriorityQueue[T]) Update(v T, priority int) {
+	i := q.h.find(v)
+	if i < 0 {
+		return
+	}
+	q.h.items[i].priority = priority
+	heap.Fix(&q.h, i)
+}
+
+// Len returns the number of items in the priority queue.
+func (q *HeapPriorityQueue[T]) Len() int {
+	return len(q.h.items)
+}
+
+// Empty returns whether the priority queue is empty.
+func (q *HeapPriorityQueue[T]) Empty() bool {
+	return len(q.h.items) == 0
+}
+
+// priorityItem is an item stored in HeapPriorityQueue.
+type priorityItem[T any] struct {
+	priority int
+	value    T
+
+	// seq is the order in which the item was pushed.
+	seq uint64
+}
+
+// priorityHeap implements heap.Interface for HeapPriorityQueue.
+type priorityHeap[T any] struct {
+	items []priorityItem[T]
+
+	// positions maps the values that can be used as map keys to the positions of their items in the heap;
+	// nil if the items are not indexed.
+	positions map[interface{}][]int
+}
+
+func (h *priorityHeap[T]) Len() int           { return len(h.items) }
+func (h *priorityHeap[T]) Less(i, j int) bool { return h.items[i].priority < h.items[j].priority }
+
+func (h *priorityHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	if h.positions != nil {
+		h.move(i, j, i)
+		h.move(j, i, j)
+	}
+}
+
+func (h *priorityHeap[T]) Push(x interface{}) { h.push(x.(priorityItem[T])) }
+func (h *priorityHeap[T]) Pop() interface{}   { return h.pop() }
+
+// push adds the item to the end of the heap.
+func (h *priorityHeap[T]) push(item priorityItem[T]) {
+	h.items = append(h.items, item)
+	if h.positions != nil {
+		h.index(len(h.items) - 1)
+	}
+}
+
+// pop removes the item at the end of the heap.
+func (h *priorityHeap[T]) pop() priorityItem[T] {
+	i := len(h.items) - 1
+	item := h.items[i]
+	if h.positions != nil {
+		h.unindex(i)
+	}
+	h.items[i] = priorityItem[T]{}
+	h.items = h.items[:i]
+	return item
+}
+
+// find returns the position of the item of the value pushed first; -1 if the value is not in the heap.
+func (h *priorityHeap[T]) find(v T) int {
+	if h.positions == nil {
+		// Indexes the items only when the priority queue is updated, so the index
+		// doesn't add overhead to the other operations.
+		h.positions = make(map[interface{}][]int, len(h.items))
+		for i := range h.items {
+			h.index(i)
+		}
+	}
+
+	found := -1
+	if hashable(reflect.ValueOf(v)) {
+		for _, i := range h.positions[v] {
+			if found < 0 || h.items[i].seq < h.items[found].seq {
+				found = i
+			}
+		}
+		return found
+	}
+	for i, item := range h.items {
+		if (found < 0 || item.seq < h.items[found].seq) && reflect.DeepEqual(item.value, v) {
+			found = i
+		}
+	}
+	return found
+}
+
+// index adds the position of the i-th item to the positions index, if its value can be used as a map key.
+func (h *priorityHeap[T]) index(i int) {
+	if v := interface{}(h.items[i].value); hashable(reflect.ValueOf(v)) {
+		h.positions[v] = append(h.positions[v], i)
+	}
+}
+
+// unindex removes the position of the i-th item from the positions index.
+func (h *priorityHeap[T]) unindex(i int) {
+	v := interface{}(h.items[i].value)
+	if !hashable(reflect.ValueOf(v)) {
+		return
+	}
+	positions := h.positions[v]
+	for k, p := range positions {
+		if p == i {
+			positions[k] = positions[len(positions)-1]
+			positions = positions[:len(positions)-1]
+			break
+		}
+	}
+	if len(positions) == 0 {
+		delete(h.positions, v)
+		return
+	}
+	h.positions[v] = positions
+}
+
+// move changes the position of the item now at the i-th position, if its value is indexed, from the
+// position from to the position to.
+func (h *priorityHeap[T]) move(i, from, to int) {
+	v := interface{}(h.items[i].value)
+	if !hashable(reflect.ValueOf(v)) {
+		return
+	}
+	positions := h.positions[v]
+	for k, p := range positions {
+		if p == from {
+			positions[k] = to
+			return
+		}
+	}
+}
+
+// hashable returns whether the value can be used as a map key, i.e. its type is comparable and, if it holds
+// interface values, the values they hold are comparable too.
+func hashable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false
+	case reflect.Interface:
+		return v.IsNil() || hashable(v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !hashable(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !hashable(v.Field(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
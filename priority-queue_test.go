@@ -0,0 +1,132 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pop pops all values from the priority queue, in the order they are popped.
+func pop[T any](q *HeapPriorityQueue[T]) []T {
+	var values []T
+	for {
+		v, ok := q.PopMin()
+		if !ok {
+			return values
+		}
+		values = append(values, v)
+	}
+}
+
+func TestHeapPriorityQueue(t *testing.T) {
+	var q HeapPriorityQueue[int]
+	q.Init()
+	for i, p := range []int{3, 1, 3, 2, 1, 3} {
+		q.Push(p, 10*p+i)
+	}
+	got := pop(&q)
+	for i := 1; i < len(got); i++ {
+		if got[i]/10 < got[i-1]/10 {
+			t.Fatalf("popped %v, want the values in ascending priority order", got)
+		}
+	}
+	if len(got) != 6 || !q.Empty() || q.Len() != 0 {
+		t.Fatalf("popped %v, want 6 values and an empty priority queue", got)
+	}
+}
+
+func TestHeapPriorityQueueUpdateDuplicates(t *testing.T) {
+	var q HeapPriorityQueue[int]
+	q.Init()
+	q.Push(5, 1)
+	q.Push(7, 1)
+	q.Push(6, 2)
+	q.Push(8, 3)
+
+	// The first pushed 1 is updated.
+	q.Update(1, 9)
+	got := []int{}
+	for i := 0; i < 2; i++ {
+		v, _ := q.PopMin()
+		got = append(got, v)
+	}
+	// The remaining 1 is still indexed after the other one was popped.
+	q.Update(1, 0)
+	q.Update(4, 0)
+	got = append(got, pop(&q)...)
+	if want := []int{2, 1, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("popped %v, want %v", got, want)
+	}
+}
+
+func TestHeapPriorityQueueUpdateNonComparable(t *testing.T) {
+	var q HeapPriorityQueue[[]int]
+	q.Init()
+	q.Push(2, []int{2})
+	q.Push(1, []int{1})
+	q.Push(3, []int{3})
+	q.Push(4, []int{3})
+	q.Update([]int{3}, 0)
+	if got, want := pop(&q), [][]int{{3}, {1}, {2}, {3}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("popped %v, want %v", got, want)
+	}
+
+	// The struct type is comparable, but the value can't be used as a map key as it holds a slice.
+	type holder struct{ v interface{} }
+	var qi HeapPriorityQueue[interface{}]
+	qi.Init()
+	qi.Push(1, 1)
+	qi.Push(2, []int{2})
+	qi.Push(4, holder{[]int{4}})
+	qi.Update([]int{2}, 0)
+	qi.Update(1, 3)
+	qi.Update(holder{[]int{4}}, 2)
+	if got, want := pop(&qi), []interface{}{[]int{2}, holder{[]int{4}}, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("popped %v, want %v", got, want)
+	}
+}
+
+func TestHeapPriorityQueueAllocs(t *testing.T) {
+	var q HeapPriorityQueue[int]
+	q.Init()
+	for i := 0; i < 64; i++ {
+		q.Push(i, i)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		v, _ := q.PopMin()
+		q.Push(v, v)
+	})
+	if allocs != 0 {
+		t.Fatalf("got %v allocations per pop and push, want 0", allocs)
+	}
+}
+
+func TestHeapPriorityQueueSuites(t *testing.T) {
+	config := testConfig()
+	config.Priorities = priorities
+	tests := &TypedTests[*TestValue]{Config: config}
+	var q HeapPriorityQueue[*TestValue]
+	runBenchmark(t, func(b *testing.B) { tests.PriorityMicroservice(b, q.Init, q.Push, q.PopMin, q.Empty) })
+	runBenchmark(t, func(b *testing.B) { tests.PriorityUpdate(b, q.Init, q.Push, q.PopMin, q.Empty, q.Update) })
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ProducerConsumer tests the data structures performance by running producer goroutines that concurrently add n items
+// to a shared data structure instance while consumer goroutines concurrently remove them, until all n items have been
+// removed. ProducerConsumer runs with every combination of 1, 2 and 4 producers and consumers (Config.Producers and
+// Config.Consumers). Consumers that find the data structure empty yield and try again.
+// ProducerConsumer reports the time to move the n items end to end (ns/op), the throughput (items/s), the average
+// latency of the add and remove operations as seen by the goroutines (ns/add and ns/remove) and the number of removes
+// that found the data structure empty (empty-removes/op).
+// The add, remove and empty functions are called concurrently, so the data structure must be safe for concurrent use.
+// ProducerConsumer tests the data structures ability to handle concurrent access, i.e. its locking or lock-free algorithm.
+func (t *Tests) ProducerConsumer(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().ProducerConsumer(b, initInstance, add, remove, empty)
+}
+
+// ProducerConsumerTestObject tests the data structures performance by running producer goroutines that concurrently add n items
+// to a shared data structure instance while consumer goroutines concurrently remove them, until all n items have been removed.
+// ProducerConsumerTestObject is a version of ProducerConsumer that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) ProducerConsumerTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
+	t.testObject().ProducerConsumer(b, initInstance, add, remove, empty)
+}
+
+// ProducerConsumer tests the data structures performance by running producer goroutines that concurrently add n items
+// to a shared data structure instance while consumer goroutines concurrently remove them, until all n items have been
+// removed. ProducerConsumer runs with every combination of 1, 2 and 4 producers and consumers (Config.Producers and
+// Config.Consumers). Consumers that find the data structure empty yield and try again.
+// ProducerConsumer reports the time to move the n items end to end (ns/op), the throughput (items/s), the average
+// latency of the add and remove operations as seen by the goroutines (ns/add and ns/remove) and the number of removes
+// that found the data structure empty (empty-removes/op).
+// The add, remove and empty functions are called concurrently, so the data structure must be safe for concurrent use.
+// ProducerConsumer tests the data structures ability to handle concurrent access, i.e. its locking or lock-free algorithm.
+func (t *TypedTests[T]) ProducerConsumer(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	t.producerConsumer(b, "ProducerConsumer", 0, initInstance, add, remove, empty)
+}
+
+// ProducerConsumerFull tests the data structures performance by filling the data structure with 10000 items (Config.FillCount)
+// and then running producer goroutines that concurrently add n items to the shared data structure instance while consumer
+// goroutines concurrently remove n items. ProducerConsumerFull runs with every combination of 1, 2 and 4 producers and consumers
+// (Config.Producers and Config.Consumers). The data structure is filled before running the test, so the fill time is not
+// included in the results.
+// ProducerConsumerFull reports the same metrics as ProducerConsumer.
+// The add, remove and empty functions are called concurrently, so the data structure must be safe for concurrent use.
+// ProducerConsumerFull tests the data structures ability to handle concurrent access when producers and consumers don't
+// compete for the same few items.
+func (t *Tests) ProducerConsumerFull(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().ProducerConsumerFull(b, initInstance, add, remove, empty)
+}
+
+// ProducerConsumerFullTestObject tests the data structures performance by filling the data structure with 10000 items (Config.FillCount)
+// and then running producer goroutines that concurrently add n items to the shared data structure instance while consumer
+// goroutines concurrently remove n items.
+// ProducerConsumerFullTestObject is a version of ProducerConsumerFull that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) ProducerConsumerFullTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
+	t.testObject().ProducerConsumerFull(b, initInstance, add, remove, empty)
+}
+
+// ProducerConsumerFull tests the data structures performance by filling the data structure with 10000 items (Config.FillCount)
+// and then running producer goroutines that concurrently add n items to the shared data structure instance while consumer
+// goroutines concurrently remove n items. ProducerConsumerFull runs with every combination of 1, 2 and 4 producers and consumers
+// (Config.Producers and Config.Consumers). The data structure is filled before running the test, so the fill time is not
+// included in the results.
+// ProducerConsumerFull reports the same metrics as ProducerConsumer.
+// The add, remove and empty functions are called concurrently, so the data structure must be safe for concurrent use.
+// ProducerConsumerFull tests the data structures ability to handle concurrent access when producers and consumers don't
+// compete for the same few items.
+func (t *TypedTests[T]) ProducerConsumerFull(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	t.producerConsumer(b, "ProducerConsumerFull", t.fillCount(), initInstance, add, remove, empty)
+}
+
+// producerConsumer runs the concurrent tests, for each combination of producers and consumers and size of the suite, as
+// sub-benchmarks named after the number of producers and consumers and the size. The data structure is filled
+// with fill items before each run.
+func (t *TypedTests[T]) producerConsumer(b *testing.B, suite string, fill int, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	t.goroutinesRun(b, func(b *testing.B, p, c int) {
+		h := t.harness(b, initInstance, add, remove, empty)
+		h.overhead = false // The data structure is called directly, not through the harness.
+		for _, count := range t.sizes(suite) {
+			h.run(count, func(b *testing.B) {
+				x := &transfer[T]{
+					add:       add,
+					remove:    remove,
+					value:     h.value,
+					producers: p,
+					consumers: c,
+					fill:      fill,
+					count:     count,
+				}
+				if t.Validate != NoValidation {
+					x.key = t.count()
+					x.seen = make([]uint32, fill+count)
+				}
+				h.reserve(fill + count)
+				b.ResetTimer()
+				var wall time.Duration
+				for n := 0; n < b.N; n++ {
+					b.StopTimer()
+					h.initInstance()
+					for i := 0; i < fill; i++ {
+						add(h.value(i))
+					}
+					wall += x.run(b)
+					if err := x.err; err != nil {
+						b.Fatal(err)
+					}
+					if x.removed != count {
+						b.Fatalf("consumers removed %d items, want %d", x.removed, count)
+					}
+					if fill == 0 && !empty() {
+						b.Fatal("empty returned false after all items were removed")
+					}
+				}
+				x.report(b, wall)
+			})
+		}
+	})
+}
+
+// goroutinesRun runs f, for each combination of producers and consumers, as sub-benchmarks named after the number of
+// producers and consumers, i.e. P2C4.
+func (t *TypedTests[T]) goroutinesRun(b *testing.B, f func(b *testing.B, producers, consumers int)) {
+	for _, p := range t.producers() {
+		for _, c := range t.consumers() {
+			if p <= 0 || c <= 0 {
+				b.Fatalf("invalid number of producers (%d) and consumers (%d)", p, c)
+			}
+			b.Run(fmt.Sprintf("P%dC%d", p, c), func(b *testing.B) {
+				f(b, p, c)
+			})
+		}
+	}
+}
+
+// transfer runs the producer and consumer goroutines of the concurrent tests. The goroutines update the transfer
+// counters when they are done; removed holds the total of the last run, while misses and the durations hold the
+// totals of all runs.
+type transfer[T any] struct {
+	add    func(v T)
+	remove func() (T, bool)
+	value  func(i int) T
+
+	producers, consumers int
+
+	// fill is the number of items the data structure is filled with before the run.
+	fill int
+
+	// count is the number of items moved from the producers to the consumers in each run.
+	count int
+
+	// key returns the index the removed values were built with; nil if validation is disabled.
+	key func(v T) int
+
+	// seen flags the items removed in the run, indexed by key; nil if validation is disabled.
+	seen []uint32
+
+	// producing is the number of producers still adding items.
+	producing int32
+
+	// budget is the number of items the consumers have yet to remove; only used if fill > 0, as
+	// otherwise the consumers run until the data structure is empty and the producers are done.
+	budget int64
+
+	removed, misses int
+	addTime         time.Duration
+	removeTime      time.Duration
+	runs            int
+
+	mu  sync.Mutex
+	err error
+}
+
+// run runs the producers and consumers once, starting them all at the same time, and returns the elapsed time.
+// run expects the benchmark timer to be stopped and leaves it running.
+func (x *transfer[T]) run(b *testing.B) time.Duration {
+	x.producing = int32(x.producers)
+	x.budget = int64(x.count)
+	x.removed = 0
+	for i := range x.seen {
+		x.seen[i] = 0
+	}
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(x.producers + x.consumers)
+	for p := 0; p < x.producers; p++ {
+		from, to := x.fill+p*x.count/x.producers, x.fill+(p+1)*x.count/x.producers
+		go x.produce(start, &wg, from, to)
+	}
+	for c := 0; c < x.consumers; c++ {
+		go x.consume(start, &wg)
+	}
+
+	b.StartTimer()
+	begin := time.Now()
+	close(start)
+	wg.Wait()
+	x.runs++
+	return time.Since(begin)
+}
+
+// produce adds the items from, inclusive, to to, exclusive, to the data structure.
+func (x *transfer[T]) produce(start <-chan struct{}, wg *sync.WaitGroup, from, to int) {
+	defer wg.Done()
+	<-start
+	begin := time.Now()
+	for i := from; i < to; i++ {
+		x.add(x.value(i))
+	}
+	elapsed := time.Since(begin)
+	atomic.AddInt32(&x.producing, -1)
+
+	x.mu.Lock()
+	x.addTime += elapsed
+	x.mu.Unlock()
+}
+
+// consume removes items from the data structure until all items were removed.
+func (x *transfer[T]) consume(start <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	<-start
+	begin := time.Now()
+	removed, misses := 0, 0
+	for x.fill == 0 || atomic.AddInt64(&x.budget, -1) >= 0 {
+		// The producers are checked before the remove, so a remove that finds the data structure empty
+		// after all producers are done means all items were removed.
+		done := atomic.LoadInt32(&x.producing) == 0
+		v, ok := x.remove()
+		if !ok {
+			if done {
+				break
+			}
+			misses++
+			if x.fill > 0 {
+				// Gives the budget back, as no item was removed.
+				atomic.AddInt64(&x.budget, 1)
+			}
+			runtime.Gosched()
+			continue
+		}
+		removed++
+		if x.key != nil {
+			x.check(v)
+		}
+	}
+	elapsed := time.Since(begin)
+
+	x.mu.Lock()
+	x.removed += removed
+	x.misses += misses
+	x.removeTime += elapsed
+	x.mu.Unlock()
+}
+
+// check fails the run if the removed value was never added or was already removed.
+func (x *transfer[T]) check(v T) {
+	i := x.key(v)
+	switch {
+	case i < 0 || i >= len(x.seen):
+		x.fail(fmt.Errorf("remove returned item %d, which was never added", i))
+	case !atomic.CompareAndSwapUint32(&x.seen[i], 0, 1):
+		x.fail(fmt.Errorf("remove returned item %d more than once", i))
+	}
+}
+
+// fail records the first error of the run.
+func (x *transfer[T]) fail(err error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.err == nil {
+		x.err = err
+	}
+}
+
+// report reports the throughput and latencies of all runs, given their total elapsed time.
+func (x *transfer[T]) report(b *testing.B, wall time.Duration) {
+	items := float64(x.count) * float64(x.runs)
+	if items == 0 || wall <= 0 {
+		return
+	}
+	b.ReportMetric(items/wall.Seconds(), "items/s")
+	b.ReportMetric(float64(x.addTime.Nanoseconds())/items, "ns/add")
+	b.ReportMetric(float64(x.removeTime.Nanoseconds())/items, "ns/remove")
+	b.ReportMetric(float64(x.misses)/float64(x.runs), "empty-removes/op")
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import "testing"
+
+// Random tests the data structures performance by running n adds and removes drawn at random, with the
+// probabilities set in Config.Mix, and then removing all remaining items. The operations are generated
+// from a seed before running the test, so all data structures are tested with the exact same operations.
+// Random tests the data structures ability to handle irregular growth and shrink, i.e. around its internal slices boundaries.
+func (t *Tests) Random(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().Random(b, initInstance, add, remove, empty)
+}
+
+// RandomTestObject tests the data structures performance by running n adds and removes drawn at random, with the
+// probabilities set in Config.Mix, and then removing all remaining items.
+// RandomTestObject is a version of Random that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) RandomTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
+	t.testObject().Random(b, initInstance, add, remove, empty)
+}
+
+// Random tests the data structures performance by running n adds and removes drawn at random, with the
+// probabilities set in Config.Mix, and then removing all remaining items. The operations are generated
+// from a seed before running the test, so all data structures are tested with the exact same operations.
+// Random tests the data structures ability to handle irregular growth and shrink, i.e. around its internal slices boundaries.
+func (t *TypedTests[T]) Random(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	if err := t.Mix.validate(); err != nil {
+		b.Fatal(err)
+	}
+	h := t.harness(b, initInstance, add, remove, empty)
+	seed := t.Mix.seed()
+	for _, count := range t.sizes("Random") {
+		h.run(count, func(b *testing.B) {
+			ops := t.Mix.ops(seed, count)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				h.init()
+				for i := 0; i < count; i++ {
+					if ops[i/64]&(1<<(i%64)) != 0 {
+						h.add(i)
+					} else {
+						h.remove()
+					}
+				}
+				h.footprint()
+				for !h.empty() {
+					h.remove()
+				}
+				h.footprint()
+			}
+			reportSeed(b, seed)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Mix configures the mix of operations of the Random test.
+type Mix struct {
+	// AddProbability is the probability of each operation being an add; the other operations are removes.
+	// Must be between 0 and 1. Defaults to 0.5, so zero can't be set to draw only removes; the removes drawn
+	// at MinLen are turned into adds anyway, so use a small probability, i.e. 0.001, instead.
+	AddProbability float64
+
+	// MinLen is the minimum number of items in the data structure. Removes drawn when the data structure
+	// has MinLen items are turned into adds.
+	MinLen int
+
+	// MaxLen, if set, is the maximum number of items in the data structure. Adds drawn when the data
+	// structure has MaxLen items are turned into removes.
+	MaxLen int
+
+	// Seed is the seed used to generate the operations, so the same seed always generates the same
+	// operations. Seed is also used to generate the uniform and duplicate priorities of the priority
+	// queue tests. Defaults to the -benchmark.seed flag or, if not set, to a random seed chosen once per
+	// process. The seed in effect is reported in each benchmark result as the seed metric, which is exact
+	// only up to 2^53, and logged.
+	Seed int64
+}
+
+var (
+	// randomSeed is the seed chosen for the process when no seed is set.
+	randomSeed     int64
+	randomSeedOnce sync.Once
+)
+
+// seed returns the seed used to generate the operations.
+func (m *Mix) seed() int64 {
+	if m.Seed != 0 {
+		return m.Seed
+	}
+	if *seedFlag != 0 {
+		return *seedFlag
+	}
+	randomSeedOnce.Do(func() {
+		// Keeps the seed within the float64 integer precision, so it is reported exactly.
+		randomSeed = time.Now().UnixNano()&(1<<53-1) | 1
+	})
+	return randomSeed
+}
+
+// reportSeed reports the seed as the seed metric and logs it, as the metric can't represent the seeds beyond
+// the float64 integer precision exactly.
+func reportSeed(b *testing.B, seed int64) {
+	b.Helper()
+	b.ReportMetric(float64(seed), "seed")
+	b.Logf("seed: %d", seed)
+}
+
+// validate checks the config is valid.
+func (m *Mix) validate() error {
+	switch {
+	case m.AddProbability < 0 || m.AddProbability > 1:
+		return fmt.Errorf("benchmark: invalid add probability %v", m.AddProbability)
+	case m.MinLen < 0:
+		return fmt.Errorf("benchmark: negative min len %d", m.MinLen)
+	case m.MaxLen != 0 && m.MaxLen <= m.MinLen:
+		return fmt.Errorf("benchmark: max len %d must be larger than min len %d", m.MaxLen, m.MinLen)
+	}
+	return nil
+}
+
+// ops generates the n operations of the Random test with the seed. ops returns a bitset
+// where the bit i is set if the i-th operation is an add and unset if it is a remove.
+func (m *Mix) ops(seed int64, n int) []uint64 {
+	p := m.AddProbability
+	if p == 0 {
+		p = 0.5
+	}
+	// Compares the random numbers against the threshold to avoid the float conversions.
+	threshold := uint64(p * (1 << 53))
+
+	rng := splitMix64(seed)
+	ops := make([]uint64, (n+63)/64)
+	l := 0
+	for i := 0; i < n; i++ {
+		add := rng.next()>>11 < threshold
+		switch {
+		case !add && l <= m.MinLen:
+			add = true
+		case add && m.MaxLen > 0 && l >= m.MaxLen:
+			add = false
+		}
+		if add {
+			ops[i/64] |= 1 << (i % 64)
+			l++
+		} else {
+			l--
+		}
+	}
+	return ops
+}
+
+// splitMix64 is a small and fast pseudo random number generator.
+// See https://prng.di.unimi.it/splitmix64.c.
+type splitMix64 uint64
+
+func (s *splitMix64) next() uint64 {
+	*s += splitMixGamma
+	return mix64(uint64(*s))
+}
+
+// splitMixGamma is the splitMix64 state increment.
+const splitMixGamma = 0x9e3779b97f4a7c15
+
+// mix64 returns the splitMix64 hash of z, so mix64(seed + (i+1)*splitMixGamma) is the i-th number
+// generated from seed.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
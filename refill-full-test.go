@@ -22,81 +22,49 @@
 // and efficiency of data structures.
 package benchmark
 
-import (
-	"strconv"
-	"testing"
-)
+import "testing"
 
 // RefillFull test the data structures performance by sequentially adding n items to the data structures and then removing all added items
 // repeating the test 100 times using the same data structure instance. But before running the test, fills the data structures
 // with n items.
 // RefillFull rests the data structures ability to fill again once it has been filled and emptied back to a certain level.
 func (t *Tests) RefillFull(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
-	initInstance()
-	for i := 0; i < fillCount; i++ {
-		add(GetTestValue(i))
-	}
-
-	for i, test := range tests {
-		// Doesn't run the first (0 items) and last (1mi) items tests
-		// as 0 items makes no sense for this test and 1mi is too slow.
-		if i == 0 || i > 6 {
-			continue
-		}
-
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
-			for n := 0; n < b.N; n++ {
-				for k := 0; k < refillCount; k++ {
-					for i := 0; i < test.count; i++ {
-						add(GetTestValue(i))
-					}
-					for i := 0; i < test.count; i++ {
-						tmp, tmp2 = remove()
-					}
-				}
-			}
-		})
-	}
-
-	for !empty() {
-		tmp, tmp2 = remove()
-	}
+	t.untyped().RefillFull(b, initInstance, add, remove, empty)
 }
 
 // RefillFullTestObject test the data structures performance by sequentially adding n items to the data structures and then removing all added items
 // repeating the test 100 times using the same data structure instance. But before running the test, fills the data structures
 // with n items.
 // RefillFullTestObject rests the data structures ability to fill again once it has been filled and emptied back to a certain level.
-// RefillFullTestObject is a copy of RefillFull that operates on *TestValue object which allows data structures that suport
+// RefillFullTestObject is a version of RefillFull that operates on *TestValue object which allows data structures that suport
 // generics to not need to perform any type cast in the benchmark tests.
 func (t *Tests) RefillFullTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
-	initInstance()
-	for i := 0; i < fillCount; i++ {
-		add(GetTestValue(i))
-	}
-
-	for i, test := range tests {
-		// Doesn't run the first (0 items) and last (1mi) items tests
-		// as 0 items makes no sense for this test and 1mi is too slow.
-		if i == 0 || i > 6 {
-			continue
-		}
+	t.testObject().RefillFull(b, initInstance, add, remove, empty)
+}
 
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
+// RefillFull test the data structures performance by sequentially adding n items to the data structures and then removing all added items
+// repeating the test 100 times using the same data structure instance. But before running the test, fills the data structures
+// with n items.
+// RefillFull rests the data structures ability to fill again once it has been filled and emptied back to a certain level.
+func (t *TypedTests[T]) RefillFull(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	fillCount, refillCount := t.fillCount(), t.refillCount()
+	for _, count := range t.sizes("RefillFull") {
+		h.run(count, func(b *testing.B) {
+			h.prepare(fillCount)
 			for n := 0; n < b.N; n++ {
 				for k := 0; k < refillCount; k++ {
-					for i := 0; i < test.count; i++ {
-						add(GetTestValue(i))
+					for i := 0; i < count; i++ {
+						h.add(i)
 					}
-					for i := 0; i < test.count; i++ {
-						tmp, tmp2 = remove()
+					h.footprint()
+					for i := 0; i < count; i++ {
+						h.remove()
 					}
 				}
 			}
+			b.ReportMetric(float64(fillCount), "fill-items")
+			b.ReportMetric(float64(refillCount), "refills")
 		})
 	}
-
-	for !empty() {
-		tmp, tmp2 = remove()
-	}
 }
@@ -0,0 +1,138 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+// replayBatch is the number of trace operations read before running them against the data structure.
+const replayBatch = 64 * 1024
+
+// Replay tests the data structures performance by replaying the operations recorded in the trace with Recorder.
+// Replay allows to test the data structures against real traffic. The data structure is initialized before
+// replaying the trace in each benchmark iteration, and again on each recorded init operation.
+// The trace is read in batches outside of the timed region, so reading the trace is not included in the results.
+func (t *Tests) Replay(b *testing.B, trace io.ReadSeeker, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().Replay(b, trace, initInstance, add, remove, empty)
+}
+
+// ReplayTestObject tests the data structures performance by replaying the operations recorded in the trace with Recorder.
+// ReplayTestObject is a version of Replay that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) ReplayTestObject(b *testing.B, trace io.ReadSeeker, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
+	t.testObject().Replay(b, trace, initInstance, add, remove, empty)
+}
+
+// Replay tests the data structures performance by replaying the operations recorded in the trace with Recorder.
+// Replay allows to test the data structures against real traffic. The data structure is initialized before
+// replaying the trace in each benchmark iteration, and again on each recorded init operation.
+// The trace is read in batches outside of the timed region, so reading the trace is not included in the results.
+// The trace is replayed as a sub-benchmark named after its number of operations, and the memory footprint is
+// measured after each batch. The results of the removes and empty calls are checked against the results recorded
+// in the trace.
+func (t *TypedTests[T]) Replay(b *testing.B, trace io.ReadSeeker, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	ops, err := countTraceOps(trace)
+	if err != nil {
+		b.Fatal(err)
+	}
+	batch := make([]TraceOp, 0, replayBatch)
+	h.run(ops, func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			b.StopTimer()
+			if _, err := trace.Seek(0, io.SeekStart); err != nil {
+				b.Fatal(err)
+			}
+			r, err := NewTraceReader(trace)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+
+			h.init()
+			for i, op, eof := 0, 0, false; !eof; {
+				b.StopTimer()
+				batch = batch[:0]
+				for len(batch) < cap(batch) {
+					o, err := r.Next()
+					if err == io.EOF {
+						eof = true
+						break
+					}
+					if err != nil {
+						b.Fatalf("trace operation %d: %v", op+len(batch), err)
+					}
+					batch = append(batch, o)
+				}
+				b.StartTimer()
+
+				for _, o := range batch {
+					switch o.Kind {
+					case OpInit:
+						h.init()
+					case OpAdd:
+						h.add(i)
+						i++
+					case OpRemove:
+						h.remove()
+						if h.tmp2 != o.Result {
+							b.Fatalf("trace operation %d: remove returned ok=%t, the trace recorded ok=%t", op, h.tmp2, o.Result)
+						}
+					case OpEmpty:
+						if e := h.empty(); e != o.Result {
+							b.Fatalf("trace operation %d: empty returned %t, the trace recorded %t", op, e, o.Result)
+						}
+					}
+					op++
+				}
+				h.footprint()
+			}
+		}
+		b.ReportMetric(float64(ops), "trace-ops")
+	})
+}
+
+// countTraceOps returns the number of operations in the trace, leaving the trace at an unspecified offset.
+func countTraceOps(trace io.ReadSeeker) (int, error) {
+	if _, err := trace.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r, err := NewTraceReader(trace)
+	if err != nil {
+		return 0, err
+	}
+	ops := 0
+	for {
+		_, err := r.Next()
+		if err == io.EOF {
+			return ops, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("trace operation %d: %v", ops, err)
+		}
+		ops++
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// LoadScenarioFile reads and validates a scenario from a JSON file.
+// See LoadScenario for the file format.
+func LoadScenarioFile(path string) (Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	defer f.Close()
+
+	s, err := LoadScenario(f)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return s, nil
+}
+
+// LoadScenario reads and validates a scenario from JSON. The JSON fields match the Scenario and Phase
+// fields, i.e.
+//
+//	{
+//		"name": "RampAndSpike",
+//		"sizes": [100, 10000],
+//		"phases": [
+//			{"name": "stable", "adds": 1, "removes": 1},
+//			{"name": "ramp", "iterations": 5000, "adds": 2, "removes": 1},
+//			{"name": "spike", "adds": 1},
+//			{"name": "drain", "drain": true}
+//		]
+//	}
+//
+// Phases without iterations run one iteration per item in the test (n iterations).
+func LoadScenario(r io.Reader) (Scenario, error) {
+	// The phases are decoded individually so decoding errors can point at the offending phase.
+	var raw struct {
+		Scenario
+		Setup  []json.RawMessage `json:"setup"`
+		Phases []json.RawMessage `json:"phases"`
+	}
+	if err := decodeJSON(r, &raw); err != nil {
+		return Scenario{}, fmt.Errorf("invalid scenario: %v", err)
+	}
+	s := raw.Scenario
+	var err error
+	if s.Setup, err = decodePhases(s.Name, "setup", raw.Setup); err != nil {
+		return Scenario{}, err
+	}
+	if s.Phases, err = decodePhases(s.Name, "phases", raw.Phases); err != nil {
+		return Scenario{}, err
+	}
+	if err := s.Validate(); err != nil {
+		return Scenario{}, err
+	}
+	return s, nil
+}
+
+// decodePhases decodes the raw JSON phases.
+func decodePhases(scenario, field string, raw []json.RawMessage) ([]Phase, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	phases := make([]Phase, len(raw))
+	for i, r := range raw {
+		if err := decodeJSON(bytes.NewReader(r), &phases[i]); err != nil {
+			return nil, fmt.Errorf("scenario %q: %s[%d]: %v", scenario, field, i, err)
+		}
+	}
+	return phases, nil
+}
+
+// decodeJSON decodes the JSON value read from r into v, rejecting unknown fields.
+func decodeJSON(r io.Reader, v interface{}) error {
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	return d.Decode(v)
+}
+
+// Validate checks the scenario is valid for each of its sizes, or for all test ranges if the
+// scenario has no sizes. Validate rejects negative values, phases that do nothing and phases that
+// would remove more items than the data structure holds.
+func (s Scenario) Validate() error {
+	if len(s.Phases) == 0 {
+		return fmt.Errorf("scenario %q: no phases", s.Name)
+	}
+	if s.Repeat < 0 {
+		return fmt.Errorf("scenario %q: negative repeat %d", s.Name, s.Repeat)
+	}
+	ss := s.Sizes
+	if ss == nil {
+		ss = sizes
+	}
+	for _, n := range ss {
+		if n < 0 {
+			return fmt.Errorf("scenario %q: negative size %d", s.Name, n)
+		}
+		if err := s.validate(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks the scenario is valid when run with n items by simulating the number of items
+// in the data structure during the setup phases and the first two benchmark iterations.
+func (s Scenario) validate(n int) error {
+	repeat := s.Repeat
+	if repeat < 1 {
+		repeat = 1
+	}
+
+	l := 0
+	var err error
+	for i, p := range s.Setup {
+		if l, err = p.simulate(l, n); err != nil {
+			return fmt.Errorf("scenario %q: setup[%d] (%q): %v", s.Name, i, p.Name, err)
+		}
+	}
+	start := l
+	for k := 0; k < 2; k++ {
+		if !s.Reuse {
+			l = start
+		}
+		for r := 0; r < repeat; r++ {
+			for i, p := range s.Phases {
+				if l, err = p.simulate(l, n); err != nil {
+					return fmt.Errorf("scenario %q: phases[%d] (%q): %v", s.Name, i, p.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// simulate returns the number of items in a data structure with l items after running the phase
+// with n items.
+func (p Phase) simulate(l, n int) (int, error) {
+	switch {
+	case p.Iterations < 0:
+		return 0, fmt.Errorf("negative iterations %d", p.Iterations)
+	case p.Adds < 0:
+		return 0, fmt.Errorf("negative adds %d", p.Adds)
+	case p.Removes < 0:
+		return 0, fmt.Errorf("negative removes %d", p.Removes)
+	case p.Drain:
+		return 0, nil
+	case p.Adds == 0 && p.Removes == 0:
+		return 0, errors.New("phase neither adds nor removes items")
+	}
+
+	iterations := p.Iterations
+	if iterations == 0 {
+		iterations = n
+	}
+	for i := 0; i < iterations; i++ {
+		if !p.RemoveFirst {
+			l += p.Adds
+		}
+		if p.Removes > 0 {
+			if l == 0 {
+				return 0, fmt.Errorf("iteration %d removes items from an empty data structure (n=%d)", i, n)
+			}
+			if p.Removes > l && !p.PartialRemoves {
+				return 0, fmt.Errorf("iteration %d removes %d items from a data structure with %d items (n=%d)", i, p.Removes, l, n)
+			}
+			l -= p.Removes
+			if l < 0 {
+				l = 0
+			}
+		}
+		if p.RemoveFirst {
+			l += p.Adds
+		}
+	}
+	return l, nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadScenario(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+
+		// err is a substring of the error; empty if the scenario is valid.
+		err string
+	}{
+		{
+			name: "Valid",
+			json: `{"name": "s", "sizes": [0, 10], "phases": [{"adds": 2, "removes": 1}, {"drain": true}]}`,
+		},
+		{
+			name: "NoPhases",
+			json: `{"name": "s"}`,
+			err:  "no phases",
+		},
+		{
+			name: "UnknownField",
+			json: `{"name": "s", "phases": [{"adds": 1, "removal": 1}]}`,
+			err:  `phases[0]: json: unknown field "removal"`,
+		},
+		{
+			name: "NegativeAdds",
+			json: `{"name": "s", "phases": [{"adds": -1}]}`,
+			err:  "phases[0] (\"\"): negative adds -1",
+		},
+		{
+			name: "NoOp",
+			json: `{"name": "s", "phases": [{"name": "noop", "iterations": 1}]}`,
+			err:  `phases[0] ("noop"): phase neither adds nor removes items`,
+		},
+		{
+			name: "RemoveFromEmpty",
+			json: `{"name": "s", "sizes": [1], "phases": [{"name": "remove", "removes": 1}]}`,
+			err:  `phases[0] ("remove"): iteration 0 removes items from an empty data structure (n=1)`,
+		},
+		{
+			name: "RemoveMoreThanHeld",
+			json: `{"name": "s", "sizes": [5], "setup": [{"iterations": 1, "adds": 1}], "phases": [{"name": "remove", "iterations": 1, "removes": 3}]}`,
+			err:  `phases[0] ("remove"): iteration 0 removes 3 items from a data structure with 1 items (n=5)`,
+		},
+		{
+			name: "RemoveMoreThanHeldLater",
+			json: `{"name": "s", "sizes": [5], "phases": [{"name": "remove", "adds": 2, "removes": 3}]}`,
+			err:  `phases[0] ("remove"): iteration 0 removes 3 items from a data structure with 2 items (n=5)`,
+		},
+		{
+			name: "PartialRemoves",
+			json: `{"name": "s", "sizes": [5], "setup": [{"iterations": 1, "adds": 1}], "phases": [{"iterations": 1, "removes": 3, "partialRemoves": true}, {"adds": 1}]}`,
+		},
+		{
+			name: "PartialRemovesFromEmpty",
+			json: `{"name": "s", "sizes": [5], "phases": [{"name": "remove", "removes": 3, "partialRemoves": true}]}`,
+			err:  `phases[0] ("remove"): iteration 0 removes items from an empty data structure (n=5)`,
+		},
+		{
+			name: "RemoveMoreThanHeldReused",
+			json: `{"name": "s", "sizes": [5], "reuse": true, "setup": [{"iterations": 3, "adds": 1}], "phases": [{"name": "remove", "iterations": 1, "removes": 2}]}`,
+			err:  `phases[0] ("remove"): iteration 0 removes 2 items from a data structure with 1 items (n=5)`,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadScenario(strings.NewReader(test.json))
+			switch {
+			case test.err == "" && err != nil:
+				t.Fatalf("got error %v, want nil", err)
+			case test.err != "" && err == nil:
+				t.Fatalf("got nil error, want %q", test.err)
+			case test.err != "" && !strings.Contains(err.Error(), test.err):
+				t.Fatalf("got error %q, want it to contain %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestBuiltinScenariosValidate(t *testing.T) {
+	scenarios := []Scenario{
+		FillScenario(),
+		RefillScenario(testRefills),
+		RefillFullScenario(testFill, testRefills),
+		SlowIncreaseScenario(),
+		SlowDecreaseScenario(testFill),
+		StableScenario(testFill),
+		MicroserviceScenario(),
+	}
+	for _, s := range scenarios {
+		if err := s.Validate(); err != nil {
+			t.Errorf("%s: %v", s.Name, err)
+		}
+	}
+}
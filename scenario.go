@@ -0,0 +1,336 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"strconv"
+	"testing"
+)
+
+// Scenario is a custom test composed of phases that add and remove items to the data structures.
+// Scenarios allow to test the data structures with workloads that match specific traffic shapes,
+// i.e. a long ramp up followed by a spike and a drain.
+//
+//	benchmark.Scenario{
+//		Name:   "RampAndSpike",
+//		Phases: []benchmark.Phase{benchmark.Stable(0), benchmark.Ramp(0, 2, 1), benchmark.Spike(0), benchmark.Drain()},
+//	}
+//
+// The scenario is run once for each size n, as a sub-benchmark named after n.
+type Scenario struct {
+	// Name is the name of the scenario. The name is used to look up the scenario sizes in
+	// Config.SuiteSizes.
+	Name string `json:"name"`
+
+	// Sizes is the number of items (n) the scenario runs with. Sizes set in the config take
+	// precedence over Sizes. Defaults to all test ranges.
+	Sizes []int `json:"sizes,omitempty"`
+
+	// Setup are the phases run after the data structure is initialized and before the timed phases.
+	// The setup phases are not included in the results.
+	Setup []Phase `json:"setup,omitempty"`
+
+	// Phases are the phases run, in order, in each benchmark iteration.
+	Phases []Phase `json:"phases"`
+
+	// Repeat is the number of times the phases are run in each benchmark iteration using the same
+	// data structure instance. Defaults to 1.
+	Repeat int `json:"repeat,omitempty"`
+
+	// Reuse, if set, initializes the data structure and runs the setup phases only once per
+	// sub-benchmark, reusing the same instance in every benchmark iteration. Otherwise a new instance is
+	// initialized in every benchmark iteration, with the initialization being included in the results.
+	Reuse bool `json:"reuse,omitempty"`
+}
+
+// Phase is a step of a scenario. A phase runs a number of iterations, each one adding Adds items
+// and removing Removes items from the data structure. The scenarios are validated to never remove
+// more items than the data structure holds, unless PartialRemoves is set.
+type Phase struct {
+	// Name is the name of the phase, used to report the phase metrics when Config.PhaseMetrics is set.
+	// Phases with the same name are reported together.
+	Name string `json:"name,omitempty"`
+
+	// Iterations is the number of iterations run by the phase. Zero runs one iteration per item
+	// in the test (n iterations).
+	Iterations int `json:"iterations,omitempty"`
+
+	// Adds is the number of items added in each iteration.
+	Adds int `json:"adds,omitempty"`
+
+	// Removes is the number of items removed in each iteration.
+	Removes int `json:"removes,omitempty"`
+
+	// PartialRemoves, if set, allows the iterations to remove less than Removes items, checking whether
+	// the data structure is empty before every remove but the first and stopping once it is empty, i.e.
+	// the slow decrease phase of the Microservice test removes up to two items. The iterations still
+	// can't remove items from an empty data structure.
+	PartialRemoves bool `json:"partialRemoves,omitempty"`
+
+	// RemoveFirst, if set, removes the items before adding the new ones in each iteration.
+	RemoveFirst bool `json:"removeFirst,omitempty"`
+
+	// Drain, if set, removes all items from the data structure, ignoring all other fields.
+	Drain bool `json:"drain,omitempty"`
+}
+
+// Fill returns a phase that adds n items to the data structure. Zero adds one item per item in the test.
+func Fill(n int) Phase {
+	return Phase{Name: "fill", Iterations: n, Adds: 1}
+}
+
+// Remove returns a phase that removes n items from the data structure. Zero removes one item per
+// item in the test.
+func Remove(n int) Phase {
+	return Phase{Name: "remove", Iterations: n, Removes: 1}
+}
+
+// Stable returns a phase that adds 1 item and removes 1 item n times. Zero runs one iteration per
+// item in the test.
+func Stable(n int) Phase {
+	return Phase{Name: "stable", Iterations: n, Adds: 1, Removes: 1}
+}
+
+// Ramp returns a phase that adds adds items and then removes removes items n times, slowly
+// expanding (adds > removes) or shrinking (adds < removes) the data structure. Zero runs one
+// iteration per item in the test.
+func Ramp(n, adds, removes int) Phase {
+	return Phase{Name: "ramp", Iterations: n, Adds: adds, Removes: removes}
+}
+
+// Spike returns a phase that quickly adds n items to the data structure. Zero adds one item per
+// item in the test.
+func Spike(n int) Phase {
+	return Phase{Name: "spike", Iterations: n, Adds: 1}
+}
+
+// Drain returns a phase that removes all items from the data structure.
+func Drain() Phase {
+	return Phase{Name: "drain", Drain: true}
+}
+
+// Named returns a copy of the phase with the given name.
+func (p Phase) Named(name string) Phase {
+	p.Name = name
+	return p
+}
+
+// Built-in scenarios--------------------------------------------------------------------------------
+
+// FillScenario returns the scenario equivalent to the Fill test.
+func FillScenario() Scenario {
+	return Scenario{
+		Name:   "Fill",
+		Phases: []Phase{Fill(0), Drain()},
+	}
+}
+
+// RefillScenario returns the scenario equivalent to the Refill test, repeating the test refills times.
+func RefillScenario(refills int) Scenario {
+	return Scenario{
+		Name:   "Refill",
+		Phases: []Phase{Fill(0), Drain()},
+		Repeat: refills,
+		Reuse:  true,
+	}
+}
+
+// RefillFullScenario returns the scenario equivalent to the RefillFull test, filling the data structure
+// with fillCount items and repeating the test refills times.
+func RefillFullScenario(fillCount, refills int) Scenario {
+	return Scenario{
+		Name:   "RefillFull",
+		Setup:  []Phase{Fill(fillCount)},
+		Phases: []Phase{Fill(0), Remove(0)},
+		Repeat: refills,
+		Reuse:  true,
+	}
+}
+
+// SlowIncreaseScenario returns the scenario equivalent to the SlowIncrease test.
+func SlowIncreaseScenario() Scenario {
+	return Scenario{
+		Name:   "SlowIncrease",
+		Phases: []Phase{Ramp(0, 2, 1), Drain()},
+	}
+}
+
+// SlowDecreaseScenario returns the scenario equivalent to the SlowDecrease test, filling the data
+// structure with fillCount items plus the items removed by the test.
+func SlowDecreaseScenario(fillCount int) Scenario {
+	return Scenario{
+		Name:   "SlowDecrease",
+		Setup:  []Phase{Fill(fillCount), Fill(0)},
+		Phases: []Phase{{Name: "ramp", Adds: 1, Removes: 2, PartialRemoves: true}},
+	}
+}
+
+// StableScenario returns the scenario equivalent to the Stable test, filling the data structure with
+// fillCount items.
+func StableScenario(fillCount int) Scenario {
+	return Scenario{
+		Name:   "Stable",
+		Setup:  []Phase{Fill(fillCount)},
+		Phases: []Phase{Stable(0)},
+		Reuse:  true,
+	}
+}
+
+// MicroserviceScenario returns the scenario equivalent to the Microservice test.
+func MicroserviceScenario() Scenario {
+	return Scenario{
+		Name: "Microservice",
+		Phases: []Phase{
+			Stable(0),
+			Ramp(0, 2, 1).Named("slow-increase"),
+			{Name: "slow-decrease", Adds: 1, Removes: 2, RemoveFirst: true, PartialRemoves: true},
+			Spike(0),
+			Stable(0).Named("high-stable"),
+			Remove(0).Named("recovery"),
+			Stable(0).Named("normal"),
+		},
+	}
+}
+
+// Scenario runner-----------------------------------------------------------------------------------
+
+// RunScenario tests the data structures performance by running the scenario.
+func (t *Tests) RunScenario(b *testing.B, s Scenario, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().RunScenario(b, s, initInstance, add, remove, empty)
+}
+
+// RunScenarioTestObject tests the data structures performance by running the scenario.
+// RunScenarioTestObject is a version of RunScenario that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) RunScenarioTestObject(b *testing.B, s Scenario, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
+	t.testObject().RunScenario(b, s, initInstance, add, remove, empty)
+}
+
+// RunScenario tests the data structures performance by running the scenario.
+func (t *TypedTests[T]) RunScenario(b *testing.B, s Scenario, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	repeat := s.Repeat
+	if repeat < 1 {
+		repeat = 1
+	}
+	for _, count := range t.sizesOr(s.Name, s.Sizes) {
+		h.run(count, func(b *testing.B) {
+			if err := s.validate(count); err != nil {
+				b.Fatal(err)
+			}
+			h.reserve(s.items(count))
+			if s.Reuse {
+				h.init()
+				h.runPhases(s.Setup, count, false)
+			}
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				if !s.Reuse {
+					h.init()
+					if len(s.Setup) > 0 {
+						b.StopTimer()
+						h.runPhases(s.Setup, count, false)
+						b.StartTimer()
+					}
+				}
+				for r := 0; r < repeat; r++ {
+					h.runPhases(s.Phases, count, true)
+				}
+			}
+		})
+	}
+}
+
+// items returns the number of values added by the longest phase of the scenario.
+func (s Scenario) items(count int) int {
+	n := count
+	for _, phases := range [][]Phase{s.Setup, s.Phases} {
+		for _, p := range phases {
+			if p.Iterations > n {
+				n = p.Iterations
+			}
+		}
+	}
+	return n
+}
+
+// runPhases runs the phases in order. measure sets whether the phase metrics, the memory footprint and the
+// latencies are measured, which they are not for the setup phases.
+func (h *harness[T]) runPhases(phases []Phase, count int, measure bool) {
+	if !measure {
+		h.untracked(func() {
+			for _, p := range phases {
+				h.runPhase(p, count)
+			}
+		})
+		return
+	}
+	for i, p := range phases {
+		name := p.Name
+		if name == "" {
+			name = "phase" + strconv.Itoa(i)
+		}
+		h.phase(name)
+		h.runPhase(p, count)
+		h.footprint()
+	}
+	h.phase("")
+}
+
+// runPhase runs the phase against the data structure.
+func (h *harness[T]) runPhase(p Phase, count int) {
+	if p.Drain {
+		for !h.empty() {
+			h.remove()
+		}
+		return
+	}
+
+	iterations := p.Iterations
+	if iterations == 0 {
+		iterations = count
+	}
+	for i := 0; i < iterations; i++ {
+		if p.RemoveFirst {
+			h.removeItems(p)
+		}
+		for k := 0; k < p.Adds; k++ {
+			h.add(i)
+		}
+		if !p.RemoveFirst {
+			h.removeItems(p)
+		}
+	}
+}
+
+// removeItems removes the items of an iteration of the phase from the data structure. The phases with partial
+// removes check whether the data structure is empty before every remove but the first, as the suites do, while
+// the other phases remove all their items, as the data structure always holds them.
+func (h *harness[T]) removeItems(p Phase) {
+	for k := 0; k < p.Removes; k++ {
+		if k > 0 && p.PartialRemoves && h.empty() {
+			return
+		}
+		h.remove()
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import "testing"
+
+// Shrink checks the data structures release their memory by sequentially adding n items to the data structure, measuring
+// the heap it holds, removing all items and measuring the heap it still holds after a garbage collection.
+// Shrink reports the heap held at the peak (peak-B and peak-B/item) and after being drained (retained-B and retained/peak),
+// regardless of Config.Memory, and fails if the data structure retains more heap than Config.ShrinkLimits allows, if set.
+// The time spent measuring the heap is not included in the results.
+// Shrink tests the data structures ability to give back the memory they no longer need.
+func (t *Tests) Shrink(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().Shrink(b, initInstance, add, remove, empty)
+}
+
+// ShrinkTestObject checks the data structures release their memory by sequentially adding n items to the data structure,
+// measuring the heap it holds, removing all items and measuring the heap it still holds after a garbage collection.
+// ShrinkTestObject is a version of Shrink that operates on *TestValue object which allows data structures that suport
+// generics to not need to perform any type cast in the benchmark tests.
+func (t *Tests) ShrinkTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
+	t.testObject().Shrink(b, initInstance, add, remove, empty)
+}
+
+// Shrink checks the data structures release their memory by sequentially adding n items to the data structure, measuring
+// the heap it holds, removing all items and measuring the heap it still holds after a garbage collection.
+// Shrink reports the heap held at the peak (peak-B and peak-B/item) and after being drained (retained-B and retained/peak),
+// regardless of Config.Memory, and fails if the data structure retains more heap than Config.ShrinkLimits allows, if set.
+// The time spent measuring the heap is not included in the results.
+// Shrink tests the data structures ability to give back the memory they no longer need.
+func (t *TypedTests[T]) Shrink(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	if h.memory == nil {
+		h.memory = newMemoryMetrics(nil)
+	}
+	for _, count := range t.sizes("Shrink") {
+		h.run(count, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				h.init()
+				for i := 0; i < count; i++ {
+					h.add(i)
+				}
+				h.footprint()
+				for !h.empty() {
+					h.remove()
+				}
+				h.footprint()
+			}
+		})
+	}
+}
@@ -22,79 +22,71 @@
 // and efficiency of data structures.
 package benchmark
 
-import (
-	"strconv"
-	"testing"
-)
+import "testing"
 
-// SlowDecrease tests the data structures performance by sequentially adding 2 items and then removing 1.
-// SlowDecrease tests the data structures ability to slowly expand while removing some elements from the data structure.
-func (t *Tests) SlowDecrease(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
-	initInstance()
-	for _, test := range tests {
-		items := test.count / 2
-		for i := 0; i <= items; i++ {
-			add(GetTestValue(i))
-		}
-	}
-
-	for i, test := range tests {
-		// Doesn't run the first (0 items) test as 0 items makes no sense for this test.
-		if i == 0 {
-			continue
-		}
+// slowDecreaseRefill is the minimum number of items the SlowDecrease test refills the data structure with.
+const slowDecreaseRefill = 10000
 
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
-			for n := 0; n < b.N; n++ {
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					tmp, tmp2 = remove()
-					if !empty() {
-						tmp, tmp2 = remove()
-					}
-				}
-			}
-		})
-	}
-
-	for !empty() {
-		tmp, tmp2 = remove()
-	}
+// SlowDecrease tests the data structures performance by filling the data structures with Config.FillCount items plus
+// the items removed by the test, and then sequentially adding 1 item and removing 2, n times.
+// SlowDecrease tests the data structures ability to slowly shrink while adding some elements to the data structure.
+func (t *Tests) SlowDecrease(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
+	t.untyped().SlowDecrease(b, initInstance, add, remove, empty)
 }
 
-// SlowDecreaseTestObject tests the data structures performance by sequentially adding 2 items and then removing 1.
-// SlowDecreaseTestObject tests the data structures ability to slowly expand while removing some elements from the data structure.
-// SlowDecreaseTestObject is a copy of SlowDecrease that operates on *TestValue object which allows data structures that suport
+// SlowDecreaseTestObject tests the data structures performance by filling the data structures with Config.FillCount items plus
+// the items removed by the test, and then sequentially adding 1 item and removing 2, n times.
+// SlowDecreaseTestObject tests the data structures ability to slowly shrink while adding some elements to the data structure.
+// SlowDecreaseTestObject is a version of SlowDecrease that operates on *TestValue object which allows data structures that suport
 // generics to not need to perform any type cast in the benchmark tests.
 func (t *Tests) SlowDecreaseTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
-	initInstance()
-	for _, test := range tests {
-		items := test.count / 2
-		for i := 0; i <= items; i++ {
-			add(GetTestValue(i))
-		}
-	}
-
-	for i, test := range tests {
-		// Doesn't run the first (0 items) test as 0 items makes no sense for this test.
-		if i == 0 {
-			continue
-		}
+	t.testObject().SlowDecrease(b, initInstance, add, remove, empty)
+}
 
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
+// SlowDecrease tests the data structures performance by filling the data structures with Config.FillCount items plus
+// the items removed by the test, and then sequentially adding 1 item and removing 2, n times.
+// SlowDecrease tests the data structures ability to slowly shrink while adding some elements to the data structure.
+func (t *TypedTests[T]) SlowDecrease(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	fillCount := t.fillCount()
+	for _, count := range t.sizes("SlowDecrease") {
+		h.run(count, func(b *testing.B) {
+			// Each iteration removes count items, so the data structure is refilled, outside of the timed
+			// region, once it holds less than the fill items plus the items an iteration removes. The data
+			// structure is refilled with the items removed by as many iterations as needed to refill at least
+			// slowDecreaseRefill items at once, so stopping and starting the timer doesn't add a measurable
+			// overhead to the iterations.
+			low, top := fillCount+count, fillCount+slowDecreaseItems(count)
+			h.prepare(top)
 			for n := 0; n < b.N; n++ {
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					tmp, tmp2 = remove()
-					if !empty() {
-						tmp, tmp2 = remove()
+				if h.len < low {
+					b.StopTimer()
+					h.fillTo(top)
+					b.StartTimer()
+				}
+				h.footprint()
+				l := h.len
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.remove()
+					if !h.empty() {
+						h.remove()
 					}
 				}
+				h.trend(l, -count)
 			}
+			h.checkLen()
+			b.ReportMetric(float64(fillCount), "fill-items")
 		})
 	}
+}
 
-	for !empty() {
-		tmp, tmp2 = remove()
+// slowDecreaseItems returns the number of items the SlowDecrease test adds to the data structure, on top of the
+// fill items, to run with n items: the items removed by the fewest iterations that remove at least
+// slowDecreaseRefill items.
+func slowDecreaseItems(n int) int {
+	if n == 0 {
+		return 0
 	}
+	return (slowDecreaseRefill + n - 1) / n * n
 }
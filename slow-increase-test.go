@@ -22,61 +22,46 @@
 // and efficiency of data structures.
 package benchmark
 
-import (
-	"strconv"
-	"testing"
-)
+import "testing"
 
-// SlowIncrease tests the data structures performance by filling the data structures with n items, and then
-// sequentially removing 2 items and adding 1.
-// SlowIncrease tests the data structures ability to slowly shrink while adding some elements to the data structure.
+// SlowIncrease tests the data structures performance by sequentially adding 2 items and then removing 1,
+// n times, growing the data structures to n items, and then removing all items.
+// SlowIncrease tests the data structures ability to slowly expand while removing some elements from the data structure.
 func (t *Tests) SlowIncrease(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
-	for i, test := range tests {
-		// Doesn't run the first (0 items) test as 0 items makes no sense for this test.
-		if i == 0 {
-			continue
-		}
-
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
-			for n := 0; n < b.N; n++ {
-				initInstance()
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					add(GetTestValue(i))
-					tmp, tmp2 = remove()
-				}
-				for !empty() {
-					tmp, tmp2 = remove()
-				}
-			}
-		})
-	}
+	t.untyped().SlowIncrease(b, initInstance, add, remove, empty)
 }
 
-// SlowIncreaseTestObject tests the data structures performance by filling the data structures with n items, and then
-// sequentially removing 2 items and adding 1.
-// SlowIncreaseTestObject tests the data structures ability to slowly shrink while adding some elements to the data structure.
-// SlowIncreaseTestObject is a copy of SlowIncrease that operates on *TestValue object which allows data structures that suport
+// SlowIncreaseTestObject tests the data structures performance by sequentially adding 2 items and then removing 1,
+// n times, growing the data structures to n items, and then removing all items.
+// SlowIncreaseTestObject tests the data structures ability to slowly expand while removing some elements from the data structure.
+// SlowIncreaseTestObject is a version of SlowIncrease that operates on *TestValue object which allows data structures that suport
 // generics to not need to perform any type cast in the benchmark tests.
 func (t *Tests) SlowIncreaseTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
-	for i, test := range tests {
-		// Doesn't run the first (0 items) test as 0 items makes no sense for this test.
-		if i == 0 {
-			continue
-		}
+	t.testObject().SlowIncrease(b, initInstance, add, remove, empty)
+}
 
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
+// SlowIncrease tests the data structures performance by sequentially adding 2 items and then removing 1,
+// n times, growing the data structures to n items, and then removing all items.
+// SlowIncrease tests the data structures ability to slowly expand while removing some elements from the data structure.
+func (t *TypedTests[T]) SlowIncrease(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	for _, count := range t.sizes("SlowIncrease") {
+		h.run(count, func(b *testing.B) {
 			for n := 0; n < b.N; n++ {
-				initInstance()
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					add(GetTestValue(i))
-					tmp, tmp2 = remove()
+				h.init()
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.add(i)
+					h.remove()
 				}
-				for !empty() {
-					tmp, tmp2 = remove()
+				h.trend(0, count)
+				h.footprint()
+				for !h.empty() {
+					h.remove()
 				}
+				h.footprint()
 			}
+			h.checkLen()
 		})
 	}
 }
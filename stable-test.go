@@ -22,69 +22,39 @@
 // and efficiency of data structures.
 package benchmark
 
-import (
-	"strconv"
-	"testing"
-)
+import "testing"
 
 // Stable tests the data structures performance by adding 1 item and removing it.
 // Stable tests the data structures ability to handle constant add/remove over n iterations.
 func (t *Tests) Stable(b *testing.B, initInstance func(), add func(v interface{}), remove func() (interface{}, bool), empty func() bool) {
-	initInstance()
-	for i := 0; i < fillCount; i++ {
-		add(GetTestValue(i))
-	}
-
-	for i, test := range tests {
-		// Doesn't run the first (0 items) test as 0 items makes no sense for this test.
-		if i == 0 {
-			continue
-		}
-
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
-			for n := 0; n < b.N; n++ {
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					tmp, tmp2 = remove()
-				}
-
-			}
-		})
-	}
-
-	for !empty() {
-		tmp, tmp2 = remove()
-	}
+	t.untyped().Stable(b, initInstance, add, remove, empty)
 }
 
 // StableTestObject tests the data structures performance by adding 1 item and removing it.
 // StableTestObject tests the data structures ability to handle constant add/remove over n iterations.
-// StableTestObject is a copy of Stable that operates on *TestValue object which allows data structures that suport
+// StableTestObject is a version of Stable that operates on *TestValue object which allows data structures that suport
 // generics to not need to perform any type cast in the benchmark tests.
 func (t *Tests) StableTestObject(b *testing.B, initInstance func(), add func(v *TestValue), remove func() (*TestValue, bool), empty func() bool) {
-	initInstance()
-	for i := 0; i < fillCount; i++ {
-		add(GetTestValue(i))
-	}
-
-	for i, test := range tests {
-		// Doesn't run the first (0 items) test as 0 items makes no sense for this test.
-		if i == 0 {
-			continue
-		}
+	t.testObject().Stable(b, initInstance, add, remove, empty)
+}
 
-		b.Run(strconv.Itoa(test.count), func(b *testing.B) {
+// Stable tests the data structures performance by adding 1 item and removing it.
+// Stable tests the data structures ability to handle constant add/remove over n iterations.
+func (t *TypedTests[T]) Stable(b *testing.B, initInstance func(), add func(v T), remove func() (T, bool), empty func() bool) {
+	h := t.harness(b, initInstance, add, remove, empty)
+	fillCount := t.fillCount()
+	for _, count := range t.sizes("Stable") {
+		h.run(count, func(b *testing.B) {
+			h.prepare(fillCount)
 			for n := 0; n < b.N; n++ {
-				for i := 0; i < test.count; i++ {
-					add(GetTestValue(i))
-					tmp, tmp2 = remove()
+				h.footprint()
+				for i := 0; i < count; i++ {
+					h.add(i)
+					h.remove()
 				}
 
 			}
+			b.ReportMetric(float64(fillCount), "fill-items")
 		})
 	}
-
-	for !empty() {
-		tmp, tmp2 = remove()
-	}
 }
@@ -0,0 +1,665 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const (
+	// testFill and testRefills are the fill and refill counts the suites are tested with.
+	testFill    = 20
+	testRefills = 3
+)
+
+// testSizes are the sizes the suites are tested with.
+var testSizes = []int{0, 1, 7, 64}
+
+// testConfig returns the config the suites are tested with.
+func testConfig() Config {
+	return Config{
+		Sizes:       testSizes,
+		FillCount:   testFill,
+		RefillCount: testRefills,
+		Validate:    FIFO,
+		Mix:         Mix{Seed: 1},
+		Producers:   []int{2},
+		Consumers:   []int{2},
+	}
+}
+
+// suite is a test suite and the operations it must run against each data structure instance.
+type suite struct {
+	name string
+
+	// run and runTestObject run the interface{} and *TestValue variants of the suite.
+	run           func(t *Tests, b *testing.B, c *counting[interface{}])
+	runTestObject func(t *Tests, b *testing.B, c *counting[*TestValue])
+
+	// want returns the operations run against the instance of the n items test. The misses, empties
+	// and order are not checked.
+	want func(n int) instance
+
+	// runs is the number of times each size runs, i.e. once per priorities distribution.
+	runs int
+
+	// concurrent sets whether the suite runs the operations concurrently, in which case only the adds,
+	// removes and len are checked.
+	concurrent bool
+}
+
+// nonEmpty returns 1 if n is not zero and 0 otherwise.
+func nonEmpty(n int) int {
+	if n > 0 {
+		return 1
+	}
+	return 0
+}
+
+// fillLike returns the operations of the tests that add n items and then remove them.
+func fillLike(n int) instance {
+	return instance{adds: n, removes: n, max: n}
+}
+
+// microserviceLike returns the operations of the Microservice tests, which leave one item in the data structure.
+func microserviceLike(n int) instance {
+	return instance{adds: 7 * n, removes: 7*n - nonEmpty(n), len: nonEmpty(n), max: n + 2*nonEmpty(n)}
+}
+
+// random returns the operations of the Random test, simulating the operations generated with the seed.
+func random(n int) instance {
+	want := instance{}
+	ops := (&Mix{}).ops(1, n)
+	for i := 0; i < n; i++ {
+		if ops[i/64]&(1<<(i%64)) != 0 {
+			want.adds++
+			want.len++
+		} else {
+			want.len--
+		}
+		if want.len > want.max {
+			want.max = want.len
+		}
+	}
+	want.removes, want.len = want.adds, 0
+	return want
+}
+
+// slowDecrease returns the operations of the SlowDecrease test, which prepares the data structure with
+// the fill items plus the items removed by the iterations run before refilling it.
+func slowDecrease(n int) instance {
+	top := testFill + slowDecreaseItems(n)
+	return instance{adds: top + n, removes: 2 * n, len: top - n, max: top + nonEmpty(n)}
+}
+
+var suites = []suite{
+	{
+		name: "Fill",
+		run:  func(t *Tests, b *testing.B, c *counting[interface{}]) { t.Fill(b, c.Init, c.Add, c.Remove, c.Empty) },
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.FillTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: fillLike,
+	},
+	{
+		name: "Refill",
+		run:  func(t *Tests, b *testing.B, c *counting[interface{}]) { t.Refill(b, c.Init, c.Add, c.Remove, c.Empty) },
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.RefillTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: testRefills * n, removes: testRefills * n, max: n}
+		},
+	},
+	{
+		name: "RefillFull",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.RefillFull(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.RefillFullTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: testFill + testRefills*n, removes: testRefills * n, len: testFill, max: testFill + n}
+		},
+	},
+	{
+		name: "SlowIncrease",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.SlowIncrease(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.SlowIncreaseTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: 2 * n, removes: 2 * n, max: n + nonEmpty(n)}
+		},
+	},
+	{
+		name: "SlowDecrease",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.SlowDecrease(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.SlowDecreaseTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: slowDecrease,
+	},
+	{
+		name: "Stable",
+		run:  func(t *Tests, b *testing.B, c *counting[interface{}]) { t.Stable(b, c.Init, c.Add, c.Remove, c.Empty) },
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.StableTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: testFill + n, removes: n, len: testFill, max: testFill + nonEmpty(n)}
+		},
+	},
+	{
+		name: "Microservice",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.Microservice(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.MicroserviceTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: microserviceLike,
+	},
+	{
+		name: "Random",
+		run:  func(t *Tests, b *testing.B, c *counting[interface{}]) { t.Random(b, c.Init, c.Add, c.Remove, c.Empty) },
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.RandomTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: random,
+	},
+	{
+		name: "DequeAlternate",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.DequeAlternate(b, c.Init, c.PushFront, c.Add, c.Remove, c.PopBack, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.DequeAlternateTestObject(b, c.Init, c.PushFront, c.Add, c.Remove, c.PopBack, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: n, removes: n, fronts: (n + 1) / 2, backs: n / 2, max: n}
+		},
+	},
+	{
+		name: "DequeReverse",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.DequeReverse(b, c.Init, c.PushFront, c.Add, c.Remove, c.PopBack, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.DequeReverseTestObject(b, c.Init, c.PushFront, c.Add, c.Remove, c.PopBack, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: 3 * n, removes: 3 * n, fronts: n, backs: n, max: n + nonEmpty(n)}
+		},
+	},
+	{
+		name: "DequeMicroservice",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.DequeMicroservice(b, c.Init, c.PushFront, c.Add, c.Remove, c.PopBack, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.DequeMicroserviceTestObject(b, c.Init, c.PushFront, c.Add, c.Remove, c.PopBack, c.Empty)
+		},
+		want: func(n int) instance {
+			want := microserviceLike(n)
+			want.fronts, want.backs = 3*n+(n+1)/2, 2*n+(n+1)/2
+			return want
+		},
+	},
+	{
+		name: "PriorityFill",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.PriorityFill(b, c.Init, c.Push, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.PriorityFillTestObject(b, c.Init, c.Push, c.Remove, c.Empty)
+		},
+		want: fillLike,
+		runs: len(priorities),
+	},
+	{
+		name: "PriorityRefill",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.PriorityRefill(b, c.Init, c.Push, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.PriorityRefillTestObject(b, c.Init, c.Push, c.Remove, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: testRefills * n, removes: testRefills * n, max: n}
+		},
+		runs: len(priorities),
+	},
+	{
+		name: "PriorityStable",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.PriorityStable(b, c.Init, c.Push, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.PriorityStableTestObject(b, c.Init, c.Push, c.Remove, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: testFill + n, removes: n, len: testFill, max: testFill + nonEmpty(n)}
+		},
+		runs: len(priorities),
+	},
+	{
+		name: "PriorityMicroservice",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.PriorityMicroservice(b, c.Init, c.Push, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.PriorityMicroserviceTestObject(b, c.Init, c.Push, c.Remove, c.Empty)
+		},
+		want: microserviceLike,
+		runs: len(priorities),
+	},
+	{
+		name: "PriorityUpdate",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.PriorityUpdate(b, c.Init, c.Push, c.Remove, c.Empty, c.Update)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.PriorityUpdateTestObject(b, c.Init, c.Push, c.Remove, c.Empty, c.Update)
+		},
+		want: func(n int) instance {
+			return instance{adds: n, removes: n, updates: n, max: n}
+		},
+		runs: len(priorities),
+	},
+	{
+		name: "PeekHeavy",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.PeekHeavy(b, c.Init, c.Add, c.Remove, c.Empty, c.Peek)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.PeekHeavyTestObject(b, c.Init, c.Add, c.Remove, c.Empty, c.Peek)
+		},
+		want: func(n int) instance {
+			return instance{adds: n, removes: n, peeks: peekRatio * n, max: n}
+		},
+	},
+	{
+		name: "Shrink",
+		run:  func(t *Tests, b *testing.B, c *counting[interface{}]) { t.Shrink(b, c.Init, c.Add, c.Remove, c.Empty) },
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.ShrinkTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: fillLike,
+	},
+	{
+		name: "Leak",
+		run:  func(t *Tests, b *testing.B, c *counting[interface{}]) { t.Leak(b, c.Init, c.Add, c.Remove, c.Empty) },
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.LeakTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: fillLike,
+	},
+	{
+		name: "ProducerConsumer",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.ProducerConsumer(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.ProducerConsumerTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want:       fillLike,
+		concurrent: true,
+	},
+	{
+		name: "ProducerConsumerFull",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.ProducerConsumerFull(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.ProducerConsumerFullTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want: func(n int) instance {
+			return instance{adds: testFill + n, removes: n, len: testFill}
+		},
+		concurrent: true,
+	},
+	{
+		name: "Linearizability",
+		run: func(t *Tests, b *testing.B, c *counting[interface{}]) {
+			t.Linearizability(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		runTestObject: func(t *Tests, b *testing.B, c *counting[*TestValue]) {
+			t.LinearizabilityTestObject(b, c.Init, c.Add, c.Remove, c.Empty)
+		},
+		want:       fillLike,
+		concurrent: true,
+	},
+}
+
+// checked returns the operations of the instance the suites are checked by.
+func (s *suite) checked(i instance) instance {
+	i.misses, i.empties, i.order = 0, 0, 0
+	if s.concurrent {
+		i.max = 0
+	}
+	return i
+}
+
+// check checks the suite ran the operations it must run against each instance.
+func (s *suite) check(t *testing.T, instances []*instance, sizes []int) {
+	t.Helper()
+	runs := s.runs
+	if runs == 0 {
+		runs = 1
+	}
+	if len(instances) != runs*len(sizes) {
+		t.Fatalf("initialized %d instances, want %d", len(instances), runs*len(sizes))
+	}
+	for k, i := range instances {
+		n := sizes[k%len(sizes)]
+		if !s.concurrent && i.misses > 0 {
+			t.Errorf("n=%d: %d removes from an empty data structure, want 0", n, i.misses)
+		}
+		if got, want := s.checked(*i), s.checked(s.want(n)); got != want {
+			t.Errorf("n=%d: got %+v, want %+v", n, got, want)
+		}
+	}
+}
+
+func TestSuites(t *testing.T) {
+	for _, s := range suites {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			tests := &Tests{Config: testConfig()}
+			c := newCounting[interface{}]()
+			runBenchmark(t, func(b *testing.B) { s.run(tests, b, c) })
+			s.check(t, c.instances, testSizes)
+		})
+	}
+}
+
+func TestSuitesTestObject(t *testing.T) {
+	for _, s := range suites {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			tests := &Tests{Config: testConfig()}
+			untyped, testObject := newCounting[interface{}](), newCounting[*TestValue]()
+			runBenchmark(t, func(b *testing.B) { s.run(tests, b, untyped) })
+			runBenchmark(t, func(b *testing.B) { s.runTestObject(tests, b, testObject) })
+			s.check(t, testObject.instances, testSizes)
+			if s.concurrent {
+				return
+			}
+			for k := range untyped.instances {
+				if got, want := *testObject.instances[k], *untyped.instances[k]; got != want {
+					t.Errorf("instance %d: TestObject got %+v, want %+v as the interface{} variant", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSuiteSizes(t *testing.T) {
+	for _, s := range suites {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			tests := &Tests{Config: testConfig()}
+			tests.SuiteSizes = map[string][]int{s.name: {3}}
+			c := newCounting[interface{}]()
+			runBenchmark(t, func(b *testing.B) { s.run(tests, b, c) })
+			s.check(t, c.instances, []int{3})
+		})
+	}
+}
+
+func TestDefaultSizes(t *testing.T) {
+	all := []int{0, 1, 10, 100, 1000, 10000, 100000, 1000000}
+	noZero, noZeroNorMillion := all[1:], all[1:7]
+	want := map[string][]int{
+		"Fill":                 all,
+		"Refill":               noZeroNorMillion,
+		"RefillFull":           noZeroNorMillion,
+		"SlowIncrease":         noZero,
+		"SlowDecrease":         noZero,
+		"Stable":               noZero,
+		"Microservice":         all,
+		"Random":               noZero,
+		"DequeAlternate":       all,
+		"DequeReverse":         noZero,
+		"DequeMicroservice":    all,
+		"PriorityFill":         all,
+		"PriorityRefill":       noZeroNorMillion,
+		"PriorityStable":       noZero,
+		"PriorityMicroservice": all,
+		"PriorityUpdate":       noZero,
+		"PeekHeavy":            noZero,
+		"ProducerConsumer":     noZeroNorMillion,
+		"ProducerConsumerFull": noZeroNorMillion,
+		"Linearizability":      all[1:4],
+		"Shrink":               noZero,
+		"Leak":                 noZeroNorMillion,
+	}
+	if sizesFlag != nil {
+		t.Skip("the -benchmark.sizes flag overrides the default sizes")
+	}
+	for _, s := range suites {
+		if got := (&Config{}).sizes(s.name); !reflect.DeepEqual(got, want[s.name]) {
+			t.Errorf("%s: got sizes %v, want %v", s.name, got, want[s.name])
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	options := map[string]func(c *Config){
+		"Overhead":     func(c *Config) { c.Overhead = true },
+		"ValuePool":    func(c *Config) { c.ValuePool = true },
+		"PhaseMetrics": func(c *Config) { c.PhaseMetrics = true },
+		"Latencies":    func(c *Config) { c.Latencies = true },
+		"Memory":       func(c *Config) { c.Memory = true },
+		"WorstOps":     func(c *Config) { c.WorstOps = 2 },
+		"ShrinkLimits": func(c *Config) { c.ShrinkLimits = &ShrinkLimits{Ratio: 1, Bytes: 1 << 16} },
+	}
+	for name, option := range options {
+		option := option
+		t.Run(name, func(t *testing.T) {
+			for _, s := range suites {
+				if s.concurrent {
+					continue
+				}
+				config := testConfig()
+				option(&config)
+				want, got := newCounting[interface{}](), newCounting[interface{}]()
+				runBenchmark(t, func(b *testing.B) { s.run(&Tests{Config: testConfig()}, b, want) })
+				runBenchmark(t, func(b *testing.B) { s.run(&Tests{Config: config}, b, got) })
+				if !reflect.DeepEqual(got.instances, want.instances) {
+					t.Errorf("%s: the operations changed", s.name)
+				}
+			}
+		})
+	}
+}
+
+func TestSuiteFailures(t *testing.T) {
+	withLen := func(c *Config, f *counting[interface{}]) { c.Len = f.Len }
+	withShrinkLimits := func(c *Config, f *counting[interface{}]) {
+		c.Sizes, c.ShrinkLimits = []int{64}, &ShrinkLimits{Bytes: 4096}
+	}
+	tests := []struct {
+		name   string
+		suite  string
+		config func(c *Config, f *counting[interface{}])
+		fault  fault
+		fails  bool
+	}{
+		{name: "PeekHeavy", suite: "PeekHeavy"},
+		{name: "PeekHeavyWrongPeek", suite: "PeekHeavy", fault: wrongPeek, fails: true},
+		{name: "PeekHeavyLostPeek", suite: "PeekHeavy", fault: lostPeek, fails: true},
+		{name: "PeekHeavyLen", suite: "PeekHeavy", config: withLen},
+		{name: "PeekHeavyLyingLen", suite: "PeekHeavy", config: withLen, fault: lyingLen, fails: true},
+		{name: "LeakHoarding", suite: "Leak", fault: hoarding, fails: true},
+		{name: "Shrink", suite: "Shrink", config: withShrinkLimits},
+		{name: "ShrinkHoarding", suite: "Shrink", config: withShrinkLimits, fault: hoarding, fails: true},
+		{name: "ProducerConsumerDuplicateRemove", suite: "ProducerConsumer", fault: duplicateRemove, fails: true},
+		{name: "ProducerConsumerFullDuplicateRemove", suite: "ProducerConsumerFull", fault: duplicateRemove, fails: true},
+		{name: "LinearizabilityDuplicateRemove", suite: "Linearizability", fault: duplicateRemove, fails: true},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			s := findSuite(t, test.suite)
+			c := newBroken[interface{}](test.fault)
+			config := testConfig()
+			config.Sizes = []int{7}
+			if test.config != nil {
+				test.config(&config, c)
+			}
+			if got := benchmarkFails(t, func(b *testing.B) { s.run(&Tests{Config: config}, b, c) }); got != test.fails {
+				t.Errorf("got failed=%t, want %t", got, test.fails)
+			}
+		})
+	}
+}
+
+func TestSlowDecreaseRefill(t *testing.T) {
+	// The data structure holds the items removed by 4 iterations on top of the fill items, so it is
+	// refilled once, before the fifth iteration.
+	const n, iterations = slowDecreaseRefill / 4, 5
+	tests := &Tests{Config: testConfig()}
+	tests.Sizes = []int{n}
+	c := newCounting[interface{}]()
+	// With b.N set to 5, the suite runs once with b.N set to 1 and then with b.N set to 5.
+	runBenchmarkN(t, iterations, func(b *testing.B) { tests.SlowDecrease(b, c.Init, c.Add, c.Remove, c.Empty) })
+	if len(c.instances) != 2 {
+		t.Fatalf("initialized %d instances, want 2", len(c.instances))
+	}
+	top := testFill + 4*n
+	i := c.instances[1]
+	want := instance{adds: top + iterations*n + 4*n, removes: iterations * 2 * n, len: top - n, max: top + 1}
+	if got := (&suite{}).checked(*i); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	// SlowDecreaseScenario prepares the data structure in every benchmark iteration in two phases, with the
+	// fill items plus the items the iteration removes, while SlowDecrease also adds the items removed by the
+	// iterations run before refilling it, so they add different items.
+	scenarios := map[string]Scenario{
+		"Fill":         FillScenario(),
+		"Refill":       RefillScenario(testRefills),
+		"RefillFull":   RefillFullScenario(testFill, testRefills),
+		"SlowIncrease": SlowIncreaseScenario(),
+		"SlowDecrease": SlowDecreaseScenario(testFill),
+		"Stable":       StableScenario(testFill),
+		"Microservice": MicroserviceScenario(),
+	}
+	for _, s := range suites {
+		scenario, ok := scenarios[s.name]
+		if !ok {
+			continue
+		}
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			tests := &Tests{Config: testConfig()}
+			want, got := newCounting[interface{}](), newCounting[interface{}]()
+			runBenchmark(t, func(b *testing.B) { s.run(tests, b, want) })
+			runBenchmark(t, func(b *testing.B) { tests.RunScenario(b, scenario, got.Init, got.Add, got.Remove, got.Empty) })
+			if len(got.instances) != len(want.instances) {
+				t.Fatalf("initialized %d instances, want %d", len(got.instances), len(want.instances))
+			}
+			for k := range want.instances {
+				g, w := *got.instances[k], *want.instances[k]
+				if s.name == "SlowDecrease" {
+					extra := slowDecreaseItems(testSizes[k]) - testSizes[k]
+					w.adds, w.len, w.max = w.adds-extra, w.len-extra, w.max-extra
+					g.order, w.order = 0, 0
+				}
+				if g != w {
+					t.Errorf("instance %d: got %+v, want %+v", k, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var trace bytes.Buffer
+	r, err := NewRecorder[interface{}](&trace, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := newCounting[interface{}]()
+	tests := &Tests{Config: testConfig()}
+	runBenchmark(t, func(b *testing.B) {
+		tests.Microservice(b, r.Init(recorded.Init), r.Add(recorded.Add), r.Remove(recorded.Remove), r.Empty(recorded.Empty))
+	})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := newCounting[interface{}]()
+	runBenchmark(t, func(b *testing.B) {
+		tests.Replay(b, bytes.NewReader(trace.Bytes()), replayed.Init, replayed.Add, replayed.Remove, replayed.Empty)
+	})
+	// Replay initializes an instance before replaying the trace, which starts with its own init.
+	if len(replayed.instances) == 0 || *replayed.instances[0] != (instance{}) {
+		t.Fatal("Replay didn't initialize an instance before replaying the trace")
+	}
+	if len(replayed.instances)-1 != len(recorded.instances) {
+		t.Fatalf("replayed %d instances, want the %d recorded ones", len(replayed.instances)-1, len(recorded.instances))
+	}
+	for k, want := range recorded.instances {
+		// The trace doesn't record the values, so the replayed items are different.
+		got := *replayed.instances[k+1]
+		got.order = want.order
+		if got != *want {
+			t.Errorf("instance %d: got %+v, want %+v", k, got, *want)
+		}
+	}
+}
+
+func TestReplayDiverged(t *testing.T) {
+	// The recorded data structure doesn't return the item it holds.
+	var trace bytes.Buffer
+	r, err := NewRecorder[interface{}](&trace, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Init(func() {})()
+	r.Add(func(v interface{}) {})(1)
+	r.Remove(func() (interface{}, bool) { return nil, false })()
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newCounting[interface{}]()
+	tests := &Tests{Config: testConfig()}
+	if !benchmarkFails(t, func(b *testing.B) {
+		tests.Replay(b, bytes.NewReader(trace.Bytes()), c.Init, c.Add, c.Remove, c.Empty)
+	}) {
+		t.Error("replaying a trace recording a different remove result didn't fail")
+	}
+}
@@ -22,8 +22,34 @@
 // and efficiency of data structures.
 package benchmark
 
+import (
+	"fmt"
+	"reflect"
+)
+
 // Tests contains benchmark tests targeted to test the performance and efficiency of data structures.
+// Tests runs the suites against data structures that store interface{} or *TestValue values.
+// Tests is a thin wrapper over TypedTests.
 type Tests struct {
+	Config
+}
+
+// TypedTests contains benchmark tests targeted to test the performance and efficiency of data structures
+// that store values of type T. TypedTests allows data structures that support generics to be tested
+// with any value type, such as int, string, user defined structs or *TestValue, without any type casts.
+type TypedTests[T any] struct {
+	Config
+
+	// Value returns the value to add to the data structures in each add call, i being the index
+	// of the item in the test. Value can be left nil when T is *TestValue, TestValue, int or interface{},
+	// in which case the values are built from GetTestValue.
+	Value func(i int) T
+
+	// Count returns the index i the value was built with. Count is used to validate the order in
+	// which the data structures return the items when Config.Validate is set, and to tell the pooled
+	// values apart when Config.Memory and Config.ValuePool are set. Count can be left nil
+	// when T is *TestValue, TestValue, int or interface{} holding *TestValue values.
+	Count func(v T) int
 }
 
 // TestValue is used as the value added in each push call to the queues.
@@ -35,31 +61,6 @@ type TestValue struct {
 	f2    int
 }
 
-// testData contains the number of items to add to the queues in each test.
-type testData struct {
-	count int
-}
-
-var (
-	tests = []testData{
-		{count: 0},
-		{count: 1},
-		{count: 10},
-		{count: 100},
-		{count: 1000},    // 1k
-		{count: 10000},   //10k
-		{count: 100000},  // 100k
-		{count: 1000000}, // 1mi
-	}
-
-	// Used to store temp values, avoiding any compiler optimizations.
-	tmp  interface{}
-	tmp2 bool
-
-	fillCount   = 10000
-	refillCount = 100
-)
-
 // Helper methods-----------------------------------------------------------------------------------
 
 // GetTestValue returns an initialized instance of *TestValue.
@@ -69,3 +70,69 @@ func GetTestValue(i int) *TestValue {
 		f2:    1, // Initializes f2 to some random value (1).
 	}
 }
+
+// untyped returns the TypedTests used to run the interface{} variants of the suites.
+func (t *Tests) untyped() *TypedTests[interface{}] {
+	return &TypedTests[interface{}]{Config: t.Config}
+}
+
+// testObject returns the TypedTests used to run the *TestValue variants of the suites.
+func (t *Tests) testObject() *TypedTests[*TestValue] {
+	return &TypedTests[*TestValue]{Config: t.Config}
+}
+
+// value returns the function used to build the values added to the data structures.
+func (t *TypedTests[T]) value() func(i int) T {
+	if t.Value != nil {
+		return t.Value
+	}
+
+	var f interface{}
+	switch any((*T)(nil)).(type) {
+	case **TestValue:
+		f = GetTestValue
+	case *TestValue:
+		f = func(i int) TestValue { return *GetTestValue(i) }
+	case *int:
+		f = func(i int) int { return i }
+	case *interface{}:
+		f = func(i int) interface{} { return GetTestValue(i) }
+	}
+	if v, ok := f.(func(i int) T); ok {
+		return v
+	}
+	panic(fmt.Sprintf("benchmark: TypedTests.Value must be set for values of type %v", reflect.TypeOf((*T)(nil)).Elem()))
+}
+
+// count returns the function used to read the index the values were built with.
+func (t *TypedTests[T]) count() func(v T) int {
+	if t.Count != nil {
+		return t.Count
+	}
+
+	var f interface{}
+	switch any((*T)(nil)).(type) {
+	case **TestValue:
+		f = func(v *TestValue) int {
+			if v == nil {
+				return -1
+			}
+			return v.count
+		}
+	case *TestValue:
+		f = func(v TestValue) int { return v.count }
+	case *int:
+		f = func(v int) int { return v }
+	case *interface{}:
+		f = func(v interface{}) int {
+			if tv, ok := v.(*TestValue); ok {
+				return tv.count
+			}
+			return -1
+		}
+	}
+	if v, ok := f.(func(v T) int); ok {
+		return v
+	}
+	panic(fmt.Sprintf("benchmark: TypedTests.Count must be set to validate values of type %v", reflect.TypeOf((*T)(nil)).Elem()))
+}
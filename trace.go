@@ -0,0 +1,280 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Trace format-------------------------------------------------------------------------------------
+//
+// A trace starts with a header composed of the traceMagic bytes, the format version and a flags byte.
+// The header is followed by the operations, each one encoded as a single byte holding the operation
+// kind in the lower bits and the operation result in the traceResult bit. When the trace has
+// timestamps (traceTimestamps flag), each operation byte is followed by the uvarint encoded number
+// of nanoseconds elapsed since the previous operation.
+
+const (
+	// TraceVersion is the version of the trace format written by Recorder.
+	TraceVersion = 1
+
+	// traceMagic identifies trace files.
+	traceMagic = "EFTRACE"
+
+	// traceTimestamps is the header flag set when the operations have timestamps.
+	traceTimestamps = 1 << 0
+
+	// traceKindMask selects the operation kind from an operation byte.
+	traceKindMask = 0x7
+
+	// traceResult is the operation byte bit that holds the operation result.
+	traceResult = 1 << 3
+)
+
+// OpKind is the kind of a recorded data structure operation.
+type OpKind uint8
+
+const (
+	// OpInit is a call to the data structure initialization function.
+	OpInit OpKind = iota
+
+	// OpAdd is a call to add.
+	OpAdd
+
+	// OpRemove is a call to remove.
+	OpRemove
+
+	// OpEmpty is a call to empty.
+	OpEmpty
+)
+
+// String returns the name of the operation kind.
+func (k OpKind) String() string {
+	switch k {
+	case OpInit:
+		return "init"
+	case OpAdd:
+		return "add"
+	case OpRemove:
+		return "remove"
+	case OpEmpty:
+		return "empty"
+	}
+	return fmt.Sprintf("OpKind(%d)", k)
+}
+
+// TraceOp is a recorded data structure operation.
+type TraceOp struct {
+	// Kind is the kind of the operation.
+	Kind OpKind
+
+	// Result is the ok result of remove or the result of empty. Result is false for init and add.
+	Result bool
+
+	// Time is the time elapsed since the trace started; zero if the trace has no timestamps.
+	Time time.Duration
+}
+
+// Recorder--------------------------------------------------------------------------------------------
+
+// Recorder records the operations run against a data structure into a trace that can be replayed
+// with Replay. Recorder wraps the data structure functions, recording every call made through them.
+//
+//	r, err := benchmark.NewRecorder[*Request](f, true)
+//	...
+//	add := r.Add(func(v *Request) { q.PushBack(v) })
+//	remove := r.Remove(func() (*Request, bool) { return q.PopFront() })
+//	...
+//	defer r.Close()
+//
+// Recorder is safe for concurrent use. The operations are recorded in the order the wrapped functions
+// return, so concurrent callers should call them under the same lock protecting the data structure
+// to get an exact trace.
+type Recorder[T any] struct {
+	mu         sync.Mutex
+	w          *bufio.Writer
+	timestamps bool
+	last       time.Time
+	buf        [binary.MaxVarintLen64 + 1]byte
+	err        error
+}
+
+// NewRecorder returns a recorder that writes the trace to w. timestamps sets whether the time of each
+// operation is recorded.
+func NewRecorder[T any](w io.Writer, timestamps bool) (*Recorder[T], error) {
+	r := &Recorder[T]{
+		w:          bufio.NewWriter(w),
+		timestamps: timestamps,
+		last:       time.Now(),
+	}
+	var flags byte
+	if timestamps {
+		flags |= traceTimestamps
+	}
+	r.w.WriteString(traceMagic)
+	r.w.WriteByte(TraceVersion)
+	if err := r.w.WriteByte(flags); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Init returns a function that calls f and records the call.
+func (r *Recorder[T]) Init(f func()) func() {
+	return func() {
+		f()
+		r.record(OpInit, false)
+	}
+}
+
+// Add returns a function that calls f and records the call.
+func (r *Recorder[T]) Add(f func(v T)) func(v T) {
+	return func(v T) {
+		f(v)
+		r.record(OpAdd, false)
+	}
+}
+
+// Remove returns a function that calls f and records the call and its ok result.
+func (r *Recorder[T]) Remove(f func() (T, bool)) func() (T, bool) {
+	return func() (T, bool) {
+		v, ok := f()
+		r.record(OpRemove, ok)
+		return v, ok
+	}
+}
+
+// Empty returns a function that calls f and records the call and its result.
+func (r *Recorder[T]) Empty(f func() bool) func() bool {
+	return func() bool {
+		e := f()
+		r.record(OpEmpty, e)
+		return e
+	}
+}
+
+// Flush writes any buffered operations to the underlying writer.
+func (r *Recorder[T]) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	return r.err
+}
+
+// Close flushes the trace. Close does not close the underlying writer.
+// The operations recorded after Close are discarded.
+func (r *Recorder[T]) Close() error {
+	err := r.Flush()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = errRecorderClosed
+	}
+	return err
+}
+
+var errRecorderClosed = errors.New("benchmark: recorder closed")
+
+// record writes the operation to the trace.
+func (r *Recorder[T]) record(k OpKind, result bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+
+	r.buf[0] = byte(k)
+	if result {
+		r.buf[0] |= traceResult
+	}
+	n := 1
+	if r.timestamps {
+		now := time.Now()
+		n += binary.PutUvarint(r.buf[1:], uint64(now.Sub(r.last)))
+		r.last = now
+	}
+	_, r.err = r.w.Write(r.buf[:n])
+}
+
+// TraceReader---------------------------------------------------------------------------------------
+
+// TraceReader reads the operations of a trace one at a time, so traces don't need to fit in memory.
+type TraceReader struct {
+	r          *bufio.Reader
+	timestamps bool
+	time       time.Duration
+}
+
+// NewTraceReader reads the trace header from r and returns a reader for the trace operations.
+func NewTraceReader(r io.Reader) (*TraceReader, error) {
+	br := bufio.NewReader(r)
+	var header [len(traceMagic) + 2]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("benchmark: invalid trace header: %v", err)
+	}
+	if string(header[:len(traceMagic)]) != traceMagic {
+		return nil, errors.New("benchmark: not a trace")
+	}
+	if v := header[len(traceMagic)]; v != TraceVersion {
+		return nil, fmt.Errorf("benchmark: unsupported trace version %d", v)
+	}
+	return &TraceReader{
+		r:          br,
+		timestamps: header[len(traceMagic)+1]&traceTimestamps != 0,
+	}, nil
+}
+
+// Next returns the next operation in the trace. Next returns io.EOF at the end of the trace.
+func (t *TraceReader) Next() (TraceOp, error) {
+	b, err := t.r.ReadByte()
+	if err != nil {
+		return TraceOp{}, err
+	}
+	op := TraceOp{
+		Kind:   OpKind(b & traceKindMask),
+		Result: b&traceResult != 0,
+	}
+	if op.Kind > OpEmpty || b&^(traceKindMask|traceResult) != 0 {
+		return TraceOp{}, fmt.Errorf("benchmark: invalid trace operation 0x%x", b)
+	}
+	if t.timestamps {
+		d, err := binary.ReadUvarint(t.r)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return TraceOp{}, err
+		}
+		t.time += time.Duration(d)
+		op.Time = t.time
+	}
+	return op, nil
+}
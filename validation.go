@@ -0,0 +1,281 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import "container/heap"
+
+// Order is the order in which a data structure is expected to return its items.
+// Order is used to validate the data structures return the items in the correct order.
+type Order int
+
+const (
+	// NoValidation disables the order validation.
+	NoValidation Order = iota
+
+	// FIFO validates the items are returned in the same order they were added (queues).
+	FIFO
+
+	// LIFO validates the items are returned in the reverse order they were added (stacks).
+	LIFO
+
+	// MinPriority validates the items with the lowest count are returned first (min heaps).
+	MinPriority
+
+	// MaxPriority validates the items with the highest count are returned first (max heaps).
+	MaxPriority
+)
+
+// String returns the name of the order.
+func (o Order) String() string {
+	switch o {
+	case NoValidation:
+		return "no validation"
+	case FIFO:
+		return "FIFO"
+	case LIFO:
+		return "LIFO"
+	case MinPriority:
+		return "min priority"
+	case MaxPriority:
+		return "max priority"
+	}
+	return "unknown order"
+}
+
+// orderModel is a reference data structure that returns the items in the expected order.
+type orderModel interface {
+	String() string
+	add(count int)
+	remove() (int, bool)
+	peek() (int, bool)
+	reset()
+}
+
+// newOrderModel returns a reference model for the order.
+func newOrderModel(o Order) orderModel {
+	switch o {
+	case FIFO:
+		return &fifoModel{}
+	case LIFO:
+		return &lifoModel{}
+	case MinPriority:
+		return &priorityModel{o: o}
+	case MaxPriority:
+		return &priorityModel{o: o, max: true}
+	}
+	panic("benchmark: invalid order " + o.String())
+}
+
+// fifoModel is a FIFO queue reference model.
+type fifoModel struct {
+	items []int
+	head  int
+}
+
+func (m *fifoModel) String() string {
+	return FIFO.String()
+}
+
+func (m *fifoModel) add(count int) {
+	if m.head > 0 && m.head*2 >= len(m.items) {
+		// Moves the remaining items to the start of the slice so it doesn't grow indefinitely.
+		n := copy(m.items, m.items[m.head:])
+		m.items, m.head = m.items[:n], 0
+	}
+	m.items = append(m.items, count)
+}
+
+func (m *fifoModel) remove() (int, bool) {
+	if m.head == len(m.items) {
+		return 0, false
+	}
+	v := m.items[m.head]
+	m.head++
+	return v, true
+}
+
+func (m *fifoModel) peek() (int, bool) {
+	if m.head == len(m.items) {
+		return 0, false
+	}
+	return m.items[m.head], true
+}
+
+func (m *fifoModel) reset() {
+	m.items, m.head = m.items[:0], 0
+}
+
+// lifoModel is a LIFO stack reference model.
+type lifoModel struct {
+	items []int
+}
+
+func (m *lifoModel) String() string {
+	return LIFO.String()
+}
+
+func (m *lifoModel) add(count int) {
+	m.items = append(m.items, count)
+}
+
+func (m *lifoModel) remove() (int, bool) {
+	if len(m.items) == 0 {
+		return 0, false
+	}
+	v := m.items[len(m.items)-1]
+	m.items = m.items[:len(m.items)-1]
+	return v, true
+}
+
+func (m *lifoModel) peek() (int, bool) {
+	if len(m.items) == 0 {
+		return 0, false
+	}
+	return m.items[len(m.items)-1], true
+}
+
+func (m *lifoModel) reset() {
+	m.items = m.items[:0]
+}
+
+// priorityModel is a min or max heap reference model.
+type priorityModel struct {
+	o     Order
+	max   bool
+	items []int
+}
+
+func (m *priorityModel) String() string {
+	return m.o.String()
+}
+
+func (m *priorityModel) add(count int) {
+	if m.max {
+		count = -count
+	}
+	heap.Push(m, count)
+}
+
+func (m *priorityModel) remove() (int, bool) {
+	if len(m.items) == 0 {
+		return 0, false
+	}
+	v := heap.Pop(m).(int)
+	if m.max {
+		v = -v
+	}
+	return v, true
+}
+
+func (m *priorityModel) peek() (int, bool) {
+	if len(m.items) == 0 {
+		return 0, false
+	}
+	v := m.items[0]
+	if m.max {
+		v = -v
+	}
+	return v, true
+}
+
+func (m *priorityModel) reset() {
+	m.items = m.items[:0]
+}
+
+// heap.Interface implementation.
+func (m *priorityModel) Len() int           { return len(m.items) }
+func (m *priorityModel) Less(i, j int) bool { return m.items[i] < m.items[j] }
+func (m *priorityModel) Swap(i, j int)      { m.items[i], m.items[j] = m.items[j], m.items[i] }
+func (m *priorityModel) Push(x interface{}) { m.items = append(m.items, x.(int)) }
+func (m *priorityModel) Pop() interface{} {
+	v := m.items[len(m.items)-1]
+	m.items = m.items[:len(m.items)-1]
+	return v
+}
+
+// dequeModel is a double-ended queue reference model. add and remove operate on the back and on
+// the front of the deque, respectively, as in a FIFO queue.
+type dequeModel struct {
+	// items is a ring buffer holding the n items starting at head.
+	items []int
+	head  int
+	n     int
+}
+
+func (m *dequeModel) String() string {
+	return "deque"
+}
+
+func (m *dequeModel) add(count int) {
+	m.grow()
+	m.items[(m.head+m.n)%len(m.items)] = count
+	m.n++
+}
+
+func (m *dequeModel) addFront(count int) {
+	m.grow()
+	m.head = (m.head - 1 + len(m.items)) % len(m.items)
+	m.items[m.head] = count
+	m.n++
+}
+
+func (m *dequeModel) remove() (int, bool) {
+	if m.n == 0 {
+		return 0, false
+	}
+	v := m.items[m.head]
+	m.head = (m.head + 1) % len(m.items)
+	m.n--
+	return v, true
+}
+
+func (m *dequeModel) removeBack() (int, bool) {
+	if m.n == 0 {
+		return 0, false
+	}
+	m.n--
+	return m.items[(m.head+m.n)%len(m.items)], true
+}
+
+func (m *dequeModel) peek() (int, bool) {
+	if m.n == 0 {
+		return 0, false
+	}
+	return m.items[m.head], true
+}
+
+func (m *dequeModel) reset() {
+	m.head, m.n = 0, 0
+}
+
+// grow doubles the ring buffer size when it is full.
+func (m *dequeModel) grow() {
+	if m.n < len(m.items) {
+		return
+	}
+	items := make([]int, 2*len(m.items)+1)
+	for i := 0; i < m.n; i++ {
+		items[i] = m.items[(m.head+i)%len(m.items)]
+	}
+	m.items, m.head = items, 0
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import "testing"
+
+// findSuite returns the suite with the name.
+func findSuite(t *testing.T, name string) suite {
+	t.Helper()
+	for _, s := range suites {
+		if s.name == name {
+			return s
+		}
+	}
+	t.Fatalf("no %s suite", name)
+	return suite{}
+}
+
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		suite    string
+		validate Order
+		fault    fault
+		fails    bool
+	}{
+		{name: "FIFO", suite: "Fill", validate: FIFO},
+		{name: "MinPriority", suite: "Fill", validate: MinPriority},
+		{name: "LIFO", suite: "Fill", validate: LIFO, fails: true},
+		{name: "MaxPriority", suite: "Fill", validate: MaxPriority, fails: true},
+		{name: "NoValidation", suite: "Fill", fault: wrongOrder},
+		{name: "WrongOrder", suite: "Fill", validate: FIFO, fault: wrongOrder, fails: true},
+		{name: "WrongOrderMicroservice", suite: "Microservice", validate: FIFO, fault: wrongOrder, fails: true},
+		{name: "Deque", suite: "DequeReverse", validate: FIFO},
+		{name: "WrongOrderDeque", suite: "DequeReverse", validate: FIFO, fault: wrongOrder, fails: true},
+		{name: "Priority", suite: "PriorityFill", validate: FIFO},
+		{name: "WrongOrderPriority", suite: "PriorityFill", validate: FIFO, fault: wrongOrder, fails: true},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			s := findSuite(t, test.suite)
+			config := testConfig()
+			config.Sizes, config.Validate = []int{7}, test.validate
+			c := newBroken[interface{}](test.fault)
+			if got := benchmarkFails(t, func(b *testing.B) { s.run(&Tests{Config: config}, b, c) }); got != test.fails {
+				t.Errorf("got failed=%t, want %t", got, test.fails)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// worstOp is one of the slowest operations of a test.
+type worstOp struct {
+	kind OpKind
+
+	// ns is the operation latency.
+	ns int64
+
+	// len is the number of items in the data structure before the operation.
+	len int
+
+	// cap is the capacity of the data structure after the operation, as returned by Config.Cap; -1 if
+	// Config.Cap is not set.
+	cap int
+
+	// op is the operation number, counting from the last time the data structure was initialized.
+	op int
+
+	// phase is the name of the test phase the operation ran in, if any.
+	phase string
+}
+
+// String returns the operation in a human readable format, i.e. "100µs add at len=65536 cap=131072 (operation 65537)".
+func (o worstOp) String() string {
+	s := fmt.Sprintf("%v %v at len=%d", time.Duration(o.ns), o.kind, o.len)
+	if o.cap >= 0 {
+		s += fmt.Sprintf(" cap=%d", o.cap)
+	}
+	if o.phase != "" {
+		s += " in phase " + o.phase
+	}
+	return s + fmt.Sprintf(" (operation %d)", o.op)
+}
+
+// worstOps tracks the n slowest operations of a test.
+type worstOps struct {
+	n int
+
+	// ops holds the slowest operations, from the slowest to the fastest.
+	ops []worstOp
+}
+
+// slower returns whether an operation with latency ns is slower than the tracked operations, i.e. if it
+// should be tracked.
+func (w *worstOps) slower(ns int64) bool {
+	return len(w.ops) < w.n || ns > w.ops[len(w.ops)-1].ns
+}
+
+// add tracks the operation, dropping the fastest tracked operation if there are already n operations.
+func (w *worstOps) add(op worstOp) {
+	i := len(w.ops)
+	for i > 0 && w.ops[i-1].ns < op.ns {
+		i--
+	}
+	if len(w.ops) < w.n {
+		w.ops = append(w.ops, worstOp{})
+	}
+	copy(w.ops[i+1:], w.ops[i:])
+	w.ops[i] = op
+}
+
+// reset removes all tracked operations.
+func (w *worstOps) reset() {
+	if w == nil {
+		return
+	}
+	w.ops = w.ops[:0]
+}
+
+// report reports the latency, the number of items and the capacity of the slowest operation as custom
+// metrics (worst-ns, worst-len and worst-cap) and logs the slowest operations.
+func (w *worstOps) report(b *testing.B) {
+	if w == nil || len(w.ops) == 0 {
+		return
+	}
+	op := w.ops[0]
+	b.ReportMetric(float64(op.ns), "worst-ns")
+	b.ReportMetric(float64(op.len), "worst-len")
+	if op.cap >= 0 {
+		b.ReportMetric(float64(op.cap), "worst-cap")
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "slowest operations with b.N=%d:", b.N)
+	for _, op := range w.ops {
+		fmt.Fprintf(&s, "\n%v", op)
+	}
+	b.Log(s.String())
+}
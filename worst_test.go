@@ -0,0 +1,78 @@
+// Copyright (c) 2018 ef-ds
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package benchmark contains benchmark tests targeted to test the performance
+// and efficiency of data structures.
+package benchmark
+
+import "testing"
+
+func TestWorstOpsExcludeSetup(t *testing.T) {
+	const n = 7
+	tests := []struct {
+		name string
+		run  func(tests *Tests, b *testing.B, c *counting[interface{}])
+
+		// ops is the number of operations each suite run times.
+		ops int
+	}{
+		{
+			name: "Stable",
+			run: func(tests *Tests, b *testing.B, c *counting[interface{}]) {
+				tests.Stable(b, c.Init, c.Add, c.Remove, c.Empty)
+			},
+			ops: 2 * n,
+		},
+		{
+			name: "PriorityStable",
+			run: func(tests *Tests, b *testing.B, c *counting[interface{}]) {
+				tests.PriorityStable(b, c.Init, c.Push, c.Remove, c.Empty)
+			},
+			ops: 2 * n,
+		},
+		{
+			name: "RefillFullScenario",
+			run: func(tests *Tests, b *testing.B, c *counting[interface{}]) {
+				tests.RunScenario(b, RefillFullScenario(testFill, testRefills), c.Init, c.Add, c.Remove, c.Empty)
+			},
+			ops: testRefills * 2 * n,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			// Config.Cap is called for every tracked operation, as every operation is one of the slowest.
+			caps := 0
+			config := testConfig()
+			config.Sizes = []int{n}
+			config.Priorities = []Priorities{AscendingPriorities}
+			config.WorstOps = 1 << 20
+			config.Cap = func() int {
+				caps++
+				return 0
+			}
+			c := newCounting[interface{}]()
+			runBenchmark(t, func(b *testing.B) { test.run(&Tests{Config: config}, b, c) })
+			if caps != test.ops {
+				t.Fatalf("tracked %d operations, want the %d operations of the test, without the setup ones", caps, test.ops)
+			}
+		})
+	}
+}